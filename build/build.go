@@ -0,0 +1,83 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// CacheDirectory is the default directory in the metarepo where completed builds are
+// recorded, kept in .git so that it is never committed
+const CacheDirectory = ".git/story/build"
+
+// Key is the content address of a build of a project, derived from the checked out commit
+// of the project and the commits of everything it transitively depends on
+func Key(project string, commits map[string]string) string {
+	var projects []string
+	for p := range commits {
+		if p != project {
+			projects = append(projects, p)
+		}
+	}
+
+	sort.Strings(projects)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s=%s\n", project, commits[project])
+	for _, p := range projects {
+		fmt.Fprintf(h, "%s=%s\n", p, commits[p])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Entry records a completed build in the cache
+type Entry struct {
+	Project string            `json:"project"`
+	Commits map[string]string `json:"commits"`
+	Built   time.Time         `json:"built"`
+}
+
+// Cache is a directory containing an entry for every key that has been built
+type Cache struct {
+	Fs        afero.Fs
+	Directory string
+}
+
+func NewCache(fs afero.Fs, directory string) *Cache {
+	if directory == "" {
+		directory = CacheDirectory
+	}
+
+	return &Cache{Fs: fs, Directory: directory}
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Directory, fmt.Sprintf("%s.json", key))
+}
+
+// Has reports whether a build with the key has already completed
+func (c *Cache) Has(key string) (bool, error) {
+	return afero.Exists(c.Fs, c.path(key))
+}
+
+// Put records a completed build under its key
+func (c *Cache) Put(key string, entry Entry) error {
+	if err := c.Fs.MkdirAll(c.Directory, os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(c.Fs, c.path(key), bytes, os.FileMode(0666))
+}
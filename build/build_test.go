@@ -0,0 +1,53 @@
+package build_test
+
+import (
+	"time"
+
+	"github.com/LGUG2Z/story/build"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Build", func() {
+	Describe("Calculating a build key", func() {
+		It("Should be the same for the same commits", func() {
+			a := build.Key("api", map[string]string{"api": "1111111", "lib-1": "2222222", "lib-2": "3333333"})
+			b := build.Key("api", map[string]string{"lib-2": "3333333", "api": "1111111", "lib-1": "2222222"})
+
+			Expect(a).To(Equal(b))
+		})
+
+		It("Should change when a dependency changes", func() {
+			a := build.Key("api", map[string]string{"api": "1111111", "lib-1": "2222222"})
+			b := build.Key("api", map[string]string{"api": "1111111", "lib-1": "4444444"})
+
+			Expect(a).NotTo(Equal(b))
+		})
+
+		It("Should depend on which project is being built", func() {
+			commits := map[string]string{"api": "1111111", "lib-1": "2222222"}
+
+			Expect(build.Key("api", commits)).NotTo(Equal(build.Key("lib-1", commits)))
+		})
+	})
+
+	Describe("Caching builds", func() {
+		It("Should only have keys which have been put", func() {
+			// Given an empty cache
+			cache := build.NewCache(afero.NewMemMapFs(), "")
+
+			has, err := cache.Has("abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(has).To(BeFalse())
+
+			// When I put a completed build
+			Expect(cache.Put("abc", build.Entry{Project: "api", Commits: map[string]string{"api": "1111111"}, Built: time.Now()})).To(Succeed())
+
+			// Then the cache has its key
+			has, err = cache.Has("abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(has).To(BeTrue())
+		})
+	})
+})
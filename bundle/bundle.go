@@ -0,0 +1,146 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// VendorDirectory is the directory of a bundle containing the packed private dependencies
+const VendorDirectory = ".story-bundle"
+
+// lockFiles pin private dependencies to git URLs, so they are left out of bundles to let
+// npm resolve the vendored tarballs instead
+var lockFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+}
+
+// Tarball is the file name of the packed tarball of a package
+func Tarball(pkg string) string {
+	return fmt.Sprintf("%s.tgz", strings.Replace(strings.TrimPrefix(pkg, "@"), "/", "-", -1))
+}
+
+// Specifier is the file: reference to the vendored tarball of a package
+func Specifier(pkg string) string {
+	return fmt.Sprintf("file:%s/%s", VendorDirectory, Tarball(pkg))
+}
+
+// Extract writes the files of a tar archive, such as the output of git archive, into a directory
+func Extract(fs afero.Fs, directory string, archive []byte) error {
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(directory, filepath.FromSlash(header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := fs.MkdirAll(path, os.FileMode(0700)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := fs.MkdirAll(filepath.Dir(path), os.FileMode(0700)); err != nil {
+				return err
+			}
+
+			contents, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+
+			if err := afero.WriteFile(fs, path, contents, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Write writes a gzipped tar archive of a project directory to use as a build context,
+// replacing its package.json and adding the vendored tarballs of its private dependencies
+func Write(w io.Writer, fs afero.Fs, project string, packageJSON []byte, vendored map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, mode os.FileMode, contents []byte) error {
+		header := &tar.Header{Name: name, Mode: int64(mode.Perm()), Size: int64(len(contents))}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		_, err := tw.Write(contents)
+		return err
+	}
+
+	if err := write("package.json", os.FileMode(0644), packageJSON); err != nil {
+		return err
+	}
+
+	var tarballs []string
+	for tarball := range vendored {
+		tarballs = append(tarballs, tarball)
+	}
+
+	sort.Strings(tarballs)
+
+	for _, tarball := range tarballs {
+		if err := write(fmt.Sprintf("%s/%s", VendorDirectory, tarball), os.FileMode(0644), vendored[tarball]); err != nil {
+			return err
+		}
+	}
+
+	err := afero.Walk(fs, project, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != project && (info.Name() == "node_modules" || info.Name() == ".git" || info.Name() == VendorDirectory) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		name, err := filepath.Rel(project, path)
+		if err != nil {
+			return err
+		}
+
+		if name == "package.json" || lockFiles[name] {
+			return nil
+		}
+
+		contents, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+
+		return write(filepath.ToSlash(name), info.Mode(), contents)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
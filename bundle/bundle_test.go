@@ -0,0 +1,81 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/LGUG2Z/story/bundle"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+func entries(archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	Expect(err).NotTo(HaveOccurred())
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+		contents, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(contents)
+	}
+}
+
+var _ = Describe("Bundle", func() {
+	It("Should name tarballs after unscoped packages", func() {
+		Expect(bundle.Tarball("@test-org/lib-1")).To(Equal("test-org-lib-1.tgz"))
+		Expect(bundle.Specifier("lib-2")).To(Equal("file:.story-bundle/lib-2.tgz"))
+	})
+
+	It("Should extract tar archives into a directory", func() {
+		// Given a tar archive like the output of git archive
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		Expect(tw.WriteHeader(&tar.Header{Name: "src/", Typeflag: tar.TypeDir, Mode: 0755})).To(Succeed())
+		Expect(tw.WriteHeader(&tar.Header{Name: "src/index.js", Typeflag: tar.TypeReg, Mode: 0644, Size: 2})).To(Succeed())
+		_, err := tw.Write([]byte("{}"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tw.Close()).To(Succeed())
+
+		// When I extract it
+		fs := afero.NewMemMapFs()
+		Expect(bundle.Extract(fs, "api", buf.Bytes())).To(Succeed())
+
+		// Then the files are in the directory
+		contents, err := afero.ReadFile(fs, "api/src/index.js")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("{}"))
+	})
+
+	It("Should write a build context with vendored tarballs and without lock files", func() {
+		// Given a project with a lock file
+		fs := afero.NewMemMapFs()
+		Expect(fs.MkdirAll("api/src", os.FileMode(0700))).To(Succeed())
+		Expect(afero.WriteFile(fs, "api/package.json", []byte(`{"name": "api"}`), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "api/package-lock.json", []byte(`{}`), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "api/src/index.js", []byte(`require("lib-1")`), os.FileMode(0666))).To(Succeed())
+
+		// When I write a build context for it
+		var buf bytes.Buffer
+		Expect(bundle.Write(&buf, fs, "api", []byte(`{"name": "api", "dependencies": {"lib-1": "file:.story-bundle/lib-1.tgz"}}`), map[string][]byte{"lib-1.tgz": []byte("tarball")})).To(Succeed())
+
+		// Then it contains the rewritten package.json, the vendored tarball and the sources
+		Expect(entries(buf.Bytes())).To(Equal(map[string]string{
+			"package.json":            `{"name": "api", "dependencies": {"lib-1": "file:.story-bundle/lib-1.tgz"}}`,
+			".story-bundle/lib-1.tgz": "tarball",
+			"src/index.js":            `require("lib-1")`,
+		}))
+	})
+})
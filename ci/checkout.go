@@ -0,0 +1,85 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/LGUG2Z/story/git"
+)
+
+var sshURL = regexp.MustCompile(`^(?:git\+)?(?:ssh://)?git@([^:/]+)[:/](.+)$`)
+
+// HTTPSURL rewrites an SSH repository URL to an HTTPS URL, so that it can be cloned with a
+// token. URLs are returned unchanged when there is no token or they are not SSH URLs.
+func HTTPSURL(repository, token string) string {
+	if token == "" {
+		return repository
+	}
+
+	m := sshURL.FindStringSubmatch(repository)
+	if m == nil {
+		return repository
+	}
+
+	return fmt.Sprintf("https://%s/%s", m[1], m[2])
+}
+
+// Target is a project to check out at Hash, or at the tip of Branch when there is no hash
+type Target struct {
+	Project    string
+	Repository string
+	Hash       string
+	Branch     string
+	// Token authenticates the clone without being stored in its remote URL
+	Token string
+}
+
+// Result is the output of checking out a target
+type Result struct {
+	Project string
+	Output  string
+	Err     error
+}
+
+// Checkout shallowly clones targets with at most parallelism clones running at once, and
+// returns their results sorted by project. A parallelism of 0 or less uses the number of CPUs.
+func Checkout(targets []Target, depth, parallelism int) []Result {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(targets))
+	semaphore := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			output, err := git.Clone(git.CloneOpts{
+				Repository: target.Repository,
+				Directory:  target.Project,
+				Branch:     target.Branch,
+				Commit:     target.Hash,
+				Depth:      depth,
+				Token:      target.Token,
+			})
+
+			results[i] = Result{Project: target.Project, Output: output, Err: err}
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Project < results[j].Project
+	})
+
+	return results
+}
@@ -0,0 +1,24 @@
+package ci_test
+
+import (
+	"github.com/LGUG2Z/story/ci"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checkout", func() {
+	Describe("Rewriting repository URLs", func() {
+		It("Should rewrite SSH URLs to HTTPS URLs without the token", func() {
+			Expect(ci.HTTPSURL("git@github.com:test-org/api.git", "secret")).To(Equal("https://github.com/test-org/api.git"))
+			Expect(ci.HTTPSURL("git+ssh://git@github.com/test-org/api.git", "secret")).To(Equal("https://github.com/test-org/api.git"))
+		})
+
+		It("Should not rewrite URLs without a token", func() {
+			Expect(ci.HTTPSURL("git@github.com:test-org/api.git", "")).To(Equal("git@github.com:test-org/api.git"))
+		})
+
+		It("Should not rewrite URLs which are not SSH URLs", func() {
+			Expect(ci.HTTPSURL("https://github.com/test-org/api.git", "secret")).To(Equal("https://github.com/test-org/api.git"))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package ci
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/LGUG2Z/story/manifest"
+	"gopkg.in/yaml.v2"
+)
+
+// Entry is an artifact to build in CI. Hash is empty for artifacts that are only in the
+// blast radius of the story, which are built from the trunk branch.
+type Entry struct {
+	Project    string `json:"project" yaml:"project"`
+	Repository string `json:"repository" yaml:"repository"`
+	Hash       string `json:"hash" yaml:"hash"`
+	Trunk      string `json:"trunk" yaml:"trunk"`
+}
+
+// Matrix returns an entry for every artifact of a story, sorted by project
+func Matrix(story *manifest.Story, trunk string) []Entry {
+	var projects []string
+	for project, artifact := range story.Artifacts {
+		if artifact {
+			projects = append(projects, project)
+		}
+	}
+
+	sort.Strings(projects)
+
+	entries := []Entry{}
+	for _, project := range projects {
+		entries = append(entries, Entry{
+			Project:    project,
+			Repository: story.AllProjects[project],
+			Hash:       story.Hashes[project],
+			Trunk:      trunk,
+		})
+	}
+
+	return entries
+}
+
+// WriteJSON writes the entries as an indented JSON array
+func WriteJSON(w io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// WriteGitHubActions writes the entries on a single line as a strategy matrix, ready to
+// be set as a job output and read with fromJSON
+func WriteGitHubActions(w io.Writer, entries []Entry) error {
+	return json.NewEncoder(w).Encode(map[string][]Entry{"include": entries})
+}
+
+// WriteGitLab writes the entries as a hidden job with a parallel matrix, which jobs in
+// a child pipeline can extend
+func WriteGitLab(w io.Writer, entries []Entry) error {
+	var matrix []yaml.MapSlice
+	for _, e := range entries {
+		matrix = append(matrix, yaml.MapSlice{
+			{Key: "PROJECT", Value: e.Project},
+			{Key: "REPOSITORY", Value: e.Repository},
+			{Key: "HASH", Value: e.Hash},
+			{Key: "TRUNK", Value: e.Trunk},
+		})
+	}
+
+	job := yaml.MapSlice{{Key: ".story-matrix", Value: yaml.MapSlice{
+		{Key: "parallel", Value: yaml.MapSlice{{Key: "matrix", Value: matrix}}},
+	}}}
+
+	bytes, err := yaml.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bytes)
+	return err
+}
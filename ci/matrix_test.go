@@ -0,0 +1,48 @@
+package ci_test
+
+import (
+	"bytes"
+
+	"github.com/LGUG2Z/story/ci"
+	"github.com/LGUG2Z/story/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Matrix", func() {
+	var story *manifest.Story
+
+	BeforeEach(func() {
+		// Given a story where api is changed, and app is an artifact in its blast radius
+		story = &manifest.Story{
+			Name:        "test-story",
+			Projects:    map[string]string{"api": "git@github.com:test-org/api.git"},
+			Hashes:      map[string]string{"api": "1111111111"},
+			Artifacts:   map[string]bool{"api": true, "app": true, "web": false},
+			AllProjects: map[string]string{"api": "git@github.com:test-org/api.git", "app": "git@github.com:test-org/app.git", "web": "git@github.com:test-org/web.git"},
+		}
+	})
+
+	It("Should include every artifact with its pinned hash", func() {
+		Expect(ci.Matrix(story, "master")).To(Equal([]ci.Entry{
+			{Project: "api", Repository: "git@github.com:test-org/api.git", Hash: "1111111111", Trunk: "master"},
+			{Project: "app", Repository: "git@github.com:test-org/app.git", Trunk: "master"},
+		}))
+	})
+
+	It("Should write a GitHub Actions matrix on a single line", func() {
+		var buf bytes.Buffer
+		Expect(ci.WriteGitHubActions(&buf, ci.Matrix(story, "master"))).To(Succeed())
+
+		Expect(buf.String()).To(HavePrefix(`{"include":[{"project":"api","repository":"git@github.com:test-org/api.git","hash":"1111111111","trunk":"master"}`))
+		Expect(buf.String()).To(HaveSuffix("]}\n"))
+	})
+
+	It("Should write a GitLab parallel matrix", func() {
+		var buf bytes.Buffer
+		Expect(ci.WriteGitLab(&buf, ci.Matrix(story, "master"))).To(Succeed())
+
+		Expect(buf.String()).To(HavePrefix(".story-matrix:\n  parallel:\n    matrix:\n    - PROJECT: api\n"))
+		Expect(buf.String()).To(ContainSubstring("HASH: \"1111111111\""))
+	})
+})
@@ -1,10 +1,11 @@
 package cli
 
 import (
-	"github.com/LGUG2Z/blastradius/blastradius"
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/LGUG2Z/story/node"
+	"github.com/fatih/color"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
 )
@@ -13,13 +14,17 @@ func AddCmd(fs afero.Fs) cli.Command {
 	return cli.Command{
 		Name:  "add",
 		Usage: "Adds a project to the current story",
-		Flags: []cli.Flag{cli.BoolFlag{Name: "ci", Usage: "clone without modifying .meta"}},
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "ci", Usage: "clone without modifying .meta"},
+			cli.IntFlag{Name: "depth", Usage: "limit how many levels of dependents are included in the blast radius"},
+		},
 		Action: func(c *cli.Context) error {
 			if !isStory {
 				return ErrNotWorkingOnAStory
 			}
 
-			if !c.Args().Present() {
+			projects := projectArgs(c)
+			if len(projects) == 0 {
 				return ErrCommandRequiresAnArgument
 			}
 
@@ -29,7 +34,7 @@ func AddCmd(fs afero.Fs) cli.Command {
 			}
 
 			if c.Bool("ci") {
-				for _, project := range c.Args() {
+				for _, project := range projects {
 					if err := ensureProjectIsCloned(fs, story, project); err != nil {
 						return err
 					}
@@ -38,67 +43,96 @@ func AddCmd(fs afero.Fs) cli.Command {
 				return nil
 			}
 
-			for _, project := range c.Args() {
-				// Add to manifest
-				if err := story.AddToManifest(story.AllProjects, project); err != nil {
-					return err
-				}
+			return addProjects(fs, story, projects, blastRadiusDepth(c, story))
+		},
+	}
+}
 
-				// Calculate the blast radius for the project and add to story
-				b := blastradius.NewCalculator()
-				if err := story.CalculateBlastRadiusForProject(fs, b, project); err != nil {
-					return err
-				}
+// addProjects adds projects to a story on their story branches, updates the blast radius,
+// artifacts and hashes of the story, and points the dependency manifests of every story
+// project at the story branches
+func addProjects(fs afero.Fs, story *manifest.Story, projects []string, depth int) error {
+	for _, project := range projects {
+		// Add to manifest
+		if err := story.AddToManifest(story.AllProjects, project); err != nil {
+			return err
+		}
+
+		// Calculate the blast radius for the project and add to story
+		b := graph.NewCalculator()
+		b.Depth = depth
+		if err := story.CalculateBlastRadiusForProject(fs, b, project); err != nil {
+			return err
+		}
+
+		// Checkout the branch
+		output, err := git.CheckoutBranch(git.CheckoutBranchOpts{
+			Branch:  story.Name,
+			Create:  true,
+			Project: project,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		printGitOutput(output, project)
+	}
 
-				// Checkout the branch
-				output, err := git.CheckoutBranch(git.CheckoutBranchOpts{
-					Branch:  story.Name,
-					Create:  true,
-					Project: project,
-				})
+	// Use the Blast Radius to update artifacts
+	story.MapBlastRadiusToArtifacts()
 
-				if err != nil {
-					return err
-				}
+	// Set the latest commit hashes for current projects
+	hashes, err := story.GetCommitHashes(fs)
+	story.Hashes = hashes
 
-				printGitOutput(output, project)
-			}
+	// Update the manifest
+	if err := story.Write(fs); err != nil {
+		return err
+	}
 
-			// Use the Blast Radius to update artifacts
-			story.MapBlastRadiusToArtifacts()
+	// Warn about story projects which will not be able to be pinned later
+	_, cycles, err := storyCycles(fs, story)
+	if err != nil {
+		return err
+	}
 
-			// Set the latest commit hashes for current projects
-			hashes, err := story.GetCommitHashes(fs)
-			story.Hashes = hashes
+	for _, cycle := range cycles {
+		color.Yellow("warning: %s", ErrStoryProjectsFormACycle(cycle))
+	}
 
-			// Update the manifest
-			if err := story.Write(fs); err != nil {
-				return err
-			}
+	var projectList []string
+	for project := range story.Projects {
+		projectList = append(projectList, project)
+	}
 
-			var projectList []string
-			for project := range story.Projects {
-				projectList = append(projectList, project)
-			}
+	// Update all of the dependency manifests where any other added project is used
+	for project := range story.Projects {
+		if ignore[project] {
+			continue
+		}
 
-			// Update all of the package.json files where any other added project is used
-			for project := range story.Projects {
-				if ignore[project] {
-					continue
-				}
+		p, err := ecosystem.Load(fs, project)
+		if err != nil {
+			if err := keepGoingOn(project, "add", err); err != nil {
+				return err
+			}
 
-				p := node.PackageJSON{}
-				if err := p.Load(fs, project); err != nil {
-					return err
-				}
+			continue
+		}
 
-				p.SetPrivateDependencyBranchesToStory(story.Name, projectList...)
-				if err := p.Write(fs, project); err != nil {
-					return err
-				}
+		p.SetDependencyBranchesToStory(story.Name, projectList...)
+		if err := p.Write(fs, project); err != nil {
+			if err := keepGoingOn(project, "add", err); err != nil {
+				return err
 			}
 
-			return nil
-		},
+			continue
+		}
+
+		recordOriginals(story, project, p)
 	}
+
+	// Keep the versions the dependencies had before the story so they can be restored
+	return story.Write(fs)
 }
@@ -7,6 +7,7 @@ import (
 
 	"fmt"
 
+	"github.com/LGUG2Z/story/dryrun"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
 	"github.com/spf13/afero"
@@ -16,7 +17,10 @@ import (
 var isStory bool
 var trunk string
 var metarepo string
+var currentProject string
+var workingDirectory string
 var ignore map[string]bool
+var config *manifest.Config
 
 var (
 	Version string
@@ -28,7 +32,7 @@ func App() *cli.App {
 		fmt.Printf("story version %s (commit %s)\n", c.App.Version, Commit)
 	}
 
-	fs := afero.NewOsFs()
+	fs := newDryRunFs(afero.NewOsFs())
 	app := cli.NewApp()
 
 	app.Name = "story"
@@ -42,14 +46,77 @@ func App() *cli.App {
 	}}
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "metarepo",
+			EnvVar: "STORY_METAREPO",
+			Usage:  "Path to the metarepo (default: the nearest directory with a .meta file and a .git directory)",
+		},
 		cli.StringFlag{
 			Name:   "trunk",
 			Value:  "master",
 			EnvVar: "STORY_TRUNK",
 		},
+		cli.StringFlag{
+			Name:   "output",
+			Value:  OutputText,
+			EnvVar: "STORY_OUTPUT",
+			Usage:  "Output format (text, json, yaml)",
+		},
+		cli.BoolFlag{
+			Name:   "keep-going",
+			EnvVar: "STORY_KEEP_GOING",
+			Usage:  "Attempt every project when an operation fails, and summarise the failures at the end",
+		},
+		cli.BoolFlag{
+			Name:   "dry-run",
+			EnvVar: "STORY_DRY_RUN",
+			Usage:  "Print the git operations, file changes and GitHub calls that would be made without making them",
+		},
 	}
 
 	app.Before = func(c *cli.Context) error {
+		if err := setOutput(c.String("output")); err != nil {
+			return err
+		}
+
+		keepGoing = c.Bool("keep-going")
+		projectErrors = nil
+
+		dryrun.Enable(c.Bool("dry-run"))
+		fs.enable(c.Bool("dry-run"))
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		// Paths given in command flags are relative to where story was run from
+		workingDirectory = wd
+
+		path := c.String("metarepo")
+		if path == "" {
+			if path, err = manifest.FindMetarepo(fs, wd); err != nil {
+				return err
+			}
+
+			if path == "" {
+				return ErrMetarepoNotFound(wd)
+			}
+		}
+
+		if path, err = filepath.Abs(path); err != nil {
+			return err
+		}
+
+		// Everything else works relative to the metarepo
+		if err := os.Chdir(path); err != nil {
+			return err
+		}
+
+		if currentProject, err = manifest.ProjectAt(fs, path, wd); err != nil {
+			return err
+		}
+
 		trunk = c.String("trunk")
 		branch, err := git.GetCurrentBranch(fs, ".")
 		if err != nil {
@@ -58,15 +125,38 @@ func App() *cli.App {
 
 		isStory = branch != trunk
 
-		path, err := os.Getwd()
+		metarepo = filepath.Base(path)
+
+		ignore, err = manifest.LoadStoryIgnore(path)
 		if err != nil {
 			return err
 		}
 
-		metarepo = filepath.Base(path)
+		if config, err = manifest.LoadConfig(fs); err != nil {
+			return err
+		}
 
-		ignore, err = manifest.LoadStoryIgnore(path)
-		return err
+		return beginJournal(fs, c.Args())
+	}
+
+	app.After = func(c *cli.Context) error {
+		// The git package is shared with other callers, so recording stops with the command
+		defer dryrun.Enable(false)
+		defer fs.enable(false)
+
+		if err := endJournal(); err != nil {
+			return err
+		}
+
+		if err := printResults(); err != nil {
+			return err
+		}
+
+		if err := printDryRun(fs); err != nil {
+			return err
+		}
+
+		return printProjectErrors()
 	}
 
 	app.Commands = []cli.Command{
@@ -75,17 +165,36 @@ func App() *cli.App {
 		ResetCmd(fs),
 		AddCmd(fs),
 		RemoveCmd(fs),
+		BumpDepCmd(fs),
 		ListCmd(fs),
 		BlastRadiusCmd(fs),
+		ExplainCmd(fs),
+		GraphCmd(fs),
+		CyclesCmd(fs),
+		VerifyCmd(fs),
+		DriftCmd(fs),
 		ArtifactsCmd(fs),
+		EnvCmd(fs),
+		ExecCmd(fs),
+		TestCmd(fs),
+		BuildCmd(fs),
+		CICmd(fs),
+		DeployManifestCmd(fs),
+		BundleCmd(fs),
 		CommitCmd(fs),
 		PushCmd(fs),
 		UnpinCmd(fs),
 		PinCmd(fs),
+		LinkCmd(fs),
+		UnlinkCmd(fs),
 		PrepareCmd(fs),
 		UpdateCmd(fs),
 		MergeCmd(fs),
+		ReleaseCmd(fs),
+		PruneTagsCmd(fs),
 		PRCmd(fs),
+		JournalCmd(fs),
+		UndoCmd(fs),
 	}
 
 	return app
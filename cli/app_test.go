@@ -1,11 +1,20 @@
 package cli_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
 	"os"
 
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
+	"strings"
 
+	"github.com/LGUG2Z/story/build"
 	"github.com/LGUG2Z/story/cli"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
@@ -13,8 +22,45 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/spf13/afero"
+	urfave "github.com/urfave/cli"
 )
 
+func untar(archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	Expect(err).NotTo(HaveOccurred())
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+		contents, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(contents)
+	}
+}
+
+func captureStdout(run func()) string {
+	r, w, err := os.Pipe()
+	Expect(err).NotTo(HaveOccurred())
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	run()
+	Expect(w.Close()).To(Succeed())
+
+	b, err := ioutil.ReadAll(r)
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(b)
+}
+
 var _ = Describe("App", func() {
 	BeforeEach(func() {
 		if err := fs.MkdirAll("test", os.FileMode(0700)); err != nil {
@@ -255,6 +301,58 @@ var _ = Describe("App", func() {
 			// TODO: Maybe check the stdout and assert on that too
 		})
 
+		It("Should recalculate the blast radius and artifacts of the story when refreshing", func() {
+			// Given an initialised metarepo where project one depends on project two
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+
+			// And a story with project two added but a stale blast radius
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote"}
+			s.BlastRadius = map[string][]string{"two": nil}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I refresh the blast radius
+			Expect(cli.App().Run([]string{"story", "blastradius", "--refresh"})).To(Succeed())
+
+			// Then the blast radius and artifacts are updated
+			s, err = manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.BlastRadius).To(HaveKeyWithValue("two", []string{"one"}))
+			Expect(s.Artifacts).To(HaveKeyWithValue("one", true))
+		})
+
+		It("Should keep the depth of the blast radius for later refreshes", func() {
+			// Given an initialised metarepo where three depends on one, which depends on two
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("three", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "three/package.json", []byte(`{"name": "three", "dependencies": {"one": "git+ssh://git@github.com:test-org/one.git"}}`), os.FileMode(0666))).To(Succeed())
+
+			// And a story with project two added
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote"}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I refresh the blast radius to a depth of one, and then refresh it again
+			Expect(cli.App().Run([]string{"story", "blastradius", "--refresh", "--depth", "1"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "blastradius", "--refresh"})).To(Succeed())
+
+			// Then both refreshes only include direct dependents
+			s, err = manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Depth).To(Equal(1))
+			Expect(s.BlastRadius).To(HaveKeyWithValue("two", []string{"one"}))
+		})
+
 		It("Should return an error if extra arguments are given", func() {
 			// Given an initialised metarepo with a story and a project added
 			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
@@ -279,6 +377,482 @@ var _ = Describe("App", func() {
 		})
 	})
 
+	Describe("Explain", func() {
+		It("Should explain why a project is in the blast radius of the story", func() {
+			// Given an initialised metarepo where project one depends on project two
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+
+			// And a story with project two added
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote"}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I explain project one, Then it succeeds
+			Expect(cli.App().Run([]string{"story", "explain", "one"})).To(Succeed())
+
+			// And explaining project two, which has been added, succeeds
+			Expect(cli.App().Run([]string{"story", "explain", "two"})).To(Succeed())
+
+			// And explaining an unrelated project returns an error
+			err = cli.App().Run([]string{"story", "explain", "three"})
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrNotInBlastRadius("three")))
+		})
+
+		It("Should return an error if no arguments are given", func() {
+			// Given an initialised metarepo with a story
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// When I try to explain a project
+			err := cli.App().Run([]string{"story", "explain"})
+
+			// Then it returns an error
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrCommandRequiresAnArgument))
+		})
+	})
+
+	Describe("Graph", func() {
+		It("Should export the dependency graph of the metarepo when not working on a story", func() {
+			// Given an initialised metarepo with a node project
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one"}`), os.FileMode(0666))).To(Succeed())
+
+			// When I export the graph, Then it succeeds
+			Expect(cli.App().Run([]string{"story", "graph", "--format", "mermaid"})).To(Succeed())
+		})
+
+		It("Should return an error if the format is not supported", func() {
+			// Given an initialised metarepo
+
+			// When I export the graph in an unknown format
+			err := cli.App().Run([]string{"story", "graph", "--format", "svg"})
+
+			// Then it returns an error
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrUnsupportedFormat("svg")))
+		})
+
+		It("Should return an error if only the story is requested when not working on a story", func() {
+			// Given an initialised metarepo not on a story
+
+			// When I export the graph of the story
+			err := cli.App().Run([]string{"story", "graph", "--story"})
+
+			// Then it returns an error
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrNotWorkingOnAStory))
+		})
+	})
+
+	Describe("Cycles", func() {
+		BeforeEach(func() {
+			// Given an initialised metarepo where projects one and two depend on each other
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two", "dependencies": {"one": "git+ssh://git@github.com:test-org/one.git"}}`), os.FileMode(0666))).To(Succeed())
+		})
+
+		It("Should return an error when circular dependencies are found", func() {
+			// When I look for cycles
+			err := cli.App().Run([]string{"story", "cycles"})
+
+			// Then it returns an error
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrCyclesFound(1)))
+		})
+
+		It("Should refuse to pin a story where the projects form a cycle", func() {
+			// Given a story with both projects added
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I try to pin the story
+			err = cli.App().Run([]string{"story", "pin"})
+
+			// Then it returns an error
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrStoryProjectsFormACycle([]string{"one", "two"})))
+		})
+
+		It("Should fail verification of a story where the projects form a cycle", func() {
+			// Given a story with both projects added, and an unrelated project with an invalid manifest
+			Expect(fs.MkdirAll("three", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "three/package.json", []byte(`{"name": "three", "dependencies": ["one"]}`), os.FileMode(0666))).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I verify the story
+			err = cli.App().Run([]string{"story", "verify"})
+
+			// Then the cycle is reported without reading the unrelated manifest
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(cli.ErrStoryProjectsFormACycle([]string{"one", "two"})))
+		})
+	})
+
+	Describe("Drift", func() {
+		BeforeEach(func() {
+			// Given projects which use different versions of lodash
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"lodash": "^4.17.0"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two", "dependencies": {"lodash": "^3.10.0"}}`), os.FileMode(0666))).To(Succeed())
+		})
+
+		It("Should report drift without failing by default", func() {
+			Expect(cli.App().Run([]string{"story", "drift", "--format", "json"})).To(Succeed())
+		})
+
+		It("Should return an error when the threshold is reached", func() {
+			err := cli.App().Run([]string{"story", "drift", "--fail-on", "2"})
+			Expect(err).To(Equal(cli.ErrDriftFound("lodash", 2)))
+		})
+	})
+
+	Describe("Pin", func() {
+		run := func(dir string, args ...string) string {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		It("Should publish prerelease versions to a registry and pin dependents to them", func() {
+			// Given a registry
+			var published []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				published = append(published, r.URL.EscapedPath())
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			// And a story where one depends on two
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "version": "2.0.0", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two", "version": "1.4.0"}`), os.FileMode(0666))).To(Succeed())
+
+			hashes := make(map[string]string)
+			for _, project := range []string{"one", "two"} {
+				run(project, "init")
+				run(project, "add", "--all")
+				run(project, "commit", "-m", "initial commit")
+				hashes[project] = run(project, "rev-parse", "HEAD")
+			}
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			s.Hashes = hashes
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I pin the story using the registry
+			Expect(cli.App().Run([]string{"story", "pin", "--registry", server.URL})).To(Succeed())
+
+			// Then dependencies are published before their dependents
+			Expect(published).To(Equal([]string{"/two", "/one"}))
+
+			// And one depends on the exact prerelease version of two
+			p := node.PackageJSON{}
+			Expect(p.Load(fs, "one")).To(Succeed())
+			Expect(p.Dependencies).To(HaveKeyWithValue("two", "1.4.0-story-test-story."+hashes["two"][:7]))
+
+			// And the original range is recorded so that it can be restored
+			s, err = manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Prerelease.Versions).To(Equal(map[string]string{"one": "2.0.0-story-test-story." + hashes["one"][:7], "two": "1.4.0-story-test-story." + hashes["two"][:7]}))
+			Expect(s.Prerelease.Ranges).To(Equal(map[string]map[string]string{"one": {"two": "git+ssh://git@github.com:test-org/two.git#test-story"}}))
+		})
+	})
+
+	Describe("Link", func() {
+		It("Should link story dependencies and restore them when unlinking", func() {
+			// Given a story where one and the ignored three depend on two
+			Expect(fs.MkdirAll("one/node_modules/two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/node_modules/two/index.js", []byte("installed"), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("three", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "three/package.json", []byte(`{"name": "three", "dependencies": {"two": "^1.0.0"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(afero.WriteFile(fs, ".storyignore", []byte("three\n"), os.FileMode(0666))).To(Succeed())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote", "three": ""}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I link the story
+			Expect(cli.App().Run([]string{"story", "link"})).To(Succeed())
+
+			// Then one uses the checkout of two
+			target, err := os.Readlink("one/node_modules/two")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal("../../two"))
+
+			// And the ignored project is not linked
+			exists, err := afero.Exists(fs, "three/node_modules")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			// When I unlink the story
+			Expect(cli.App().Run([]string{"story", "unlink"})).To(Succeed())
+
+			// Then the installed copy is restored
+			b, err := afero.ReadFile(fs, "one/node_modules/two/index.js")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("installed"))
+		})
+	})
+
+	Describe("Tags", func() {
+		run := func(dir string, args ...string) string {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		var hash string
+
+		BeforeEach(func() {
+			// Given a project two with an origin remote
+			Expect(fs.MkdirAll("remote", os.FileMode(0700))).To(Succeed())
+			run("remote", "init", "--bare")
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+			run("two", "init")
+			run("two", "remote", "add", "origin", "../remote")
+			run("two", "add", "package.json")
+			run("two", "commit", "-m", "initial commit")
+			hash = run("two", "rev-parse", "HEAD")
+
+			// And a project one which depends on two
+			Expect(fs.MkdirAll("remote-one", os.FileMode(0700))).To(Succeed())
+			run("remote-one", "init", "--bare")
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "init")
+			run("one", "remote", "add", "origin", "../remote-one")
+			run("one", "add", "package.json")
+			run("one", "commit", "-m", "initial commit")
+		})
+
+		It("Should pin dependencies to pushed story tags", func() {
+			// Given a story with both projects
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			s.Hashes = map[string]string{"one": run("one", "rev-parse", "HEAD"), "two": hash}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I pin the story to tags twice
+			Expect(cli.App().Run([]string{"story", "pin", "--tags"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "pin", "--tags"})).To(Succeed())
+
+			// Then the tags are numbered and pushed
+			remote, err := git.RemoteTags("two", "origin", "story/test-story/*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote).To(ConsistOf("story/test-story/1", "story/test-story/2"))
+			Expect(run("two", "rev-parse", "story/test-story/2^{commit}")).To(Equal(hash))
+
+			// And one is pinned to the latest tag
+			p := node.PackageJSON{}
+			Expect(p.Load(fs, "one")).To(Succeed())
+			Expect(p.Dependencies).To(HaveKeyWithValue("two", "git+ssh://git@github.com:test-org/two.git#story/test-story/2"))
+
+			s, err = manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Tags).To(Equal(map[string]string{"one": "story/test-story/2", "two": "story/test-story/2"}))
+		})
+
+		It("Should number tags after the ones already pushed by others", func() {
+			// Given a story whose first tag was pushed from another clone
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote"}
+			s.Hashes = map[string]string{"two": hash}
+			Expect(s.Write(fs)).To(Succeed())
+
+			run("two", "tag", "story/test-story/1")
+			run("two", "push", "origin", "story/test-story/1")
+			run("two", "tag", "--delete", "story/test-story/1")
+
+			// When I pin the story to tags
+			Expect(cli.App().Run([]string{"story", "pin", "--tags"})).To(Succeed())
+
+			// Then the next tag is used
+			s, err = manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Tags).To(Equal(map[string]string{"two": "story/test-story/2"}))
+		})
+
+		It("Should delete the tags already pushed when a project can't be tagged", func() {
+			// Given a story where two has no remote to push to
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			s.Hashes = map[string]string{"one": run("one", "rev-parse", "HEAD"), "two": hash}
+			Expect(s.Write(fs)).To(Succeed())
+			run("two", "remote", "remove", "origin")
+
+			// When I pin the story to tags
+			Expect(cli.App().Run([]string{"story", "pin", "--tags"})).NotTo(Succeed())
+
+			// Then the tag of one is deleted locally and from its remote
+			local, err := git.Tags("one", "story/test-story/*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(local).To(BeEmpty())
+
+			remote, err := git.RemoteTags("one", "origin", "story/test-story/*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote).To(BeEmpty())
+		})
+
+		It("Should prune the superseded tags of merged stories", func() {
+			// Given an archived story which was pinned to tags twice
+			for _, tag := range []string{"story/test-story/1", "story/test-story/2"} {
+				run("two", "tag", tag)
+				run("two", "push", "origin", tag)
+			}
+
+			s := manifest.Story{Name: "test-story", Projects: map[string]string{"two": "external/remote"}, Tags: map[string]string{"two": "story/test-story/2"}}
+			Expect(fs.MkdirAll("story", os.FileMode(0700))).To(Succeed())
+			Expect(s.WriteToLocation(fs, "story/test-story.json")).To(Succeed())
+
+			// When I prune the tags
+			Expect(cli.App().Run([]string{"story", "prune-tags"})).To(Succeed())
+
+			// Then only the tag the manifests were last pinned to is kept locally and on the remote
+			local, err := git.Tags("two", "story/test-story/*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(local).To(ConsistOf("story/test-story/2"))
+
+			remote, err := git.RemoteTags("two", "origin", "story/test-story/*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote).To(ConsistOf("story/test-story/2"))
+		})
+
+		It("Should return an error when pinning to a registry and tags", func() {
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			err := cli.App().Run([]string{"story", "pin", "--tags", "--registry", "http://localhost"})
+			Expect(err).To(Equal(cli.ErrPinModesAreExclusive))
+		})
+	})
+
+	Describe("Release", func() {
+		run := func(dir string, args ...string) {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+		}
+
+		BeforeEach(func() {
+			// Given a library two which has gained a feature since it was last released
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two", "version": "1.4.0"}`), os.FileMode(0666))).To(Succeed())
+			run("two", "init")
+			run("two", "add", "package.json")
+			run("two", "commit", "-m", "initial commit")
+			run("two", "tag", "v1.4.0")
+			Expect(afero.WriteFile(fs, "two/login.js", []byte{}, os.FileMode(0666))).To(Succeed())
+			run("two", "add", "login.js")
+			run("two", "commit", "-m", "feat: add login")
+
+			// And a project one which consumes two
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "version": "2.0.0", "dependencies": {"two": "^1.4.0"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "init")
+			run("one", "add", "package.json")
+			run("one", "commit", "-m", "initial commit")
+
+			// And a merged story which changed two
+			s := manifest.Story{Name: "test-story", Orgranisation: "test-org", Projects: map[string]string{"two": "external/remote"}}
+			Expect(fs.MkdirAll("story", os.FileMode(0700))).To(Succeed())
+			Expect(s.WriteToLocation(fs, "story/test-story.json")).To(Succeed())
+		})
+
+		It("Should release the story projects and their dependents", func() {
+			// When I release the story
+			Expect(cli.App().Run([]string{"story", "release", "test-story"})).To(Succeed())
+
+			// Then two has a minor release for its feature
+			two := node.PackageJSON{}
+			Expect(two.Load(fs, "two")).To(Succeed())
+			Expect(two.Version()).To(Equal("1.5.0"))
+			tag, err := git.LatestTag("two", "v*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tag).To(Equal("v1.5.0"))
+
+			// And one has a patch release consuming the new version of two
+			one := node.PackageJSON{}
+			Expect(one.Load(fs, "one")).To(Succeed())
+			Expect(one.Version()).To(Equal("2.0.1"))
+			Expect(one.Dependencies).To(HaveKeyWithValue("two", "^1.5.0"))
+
+			// And the releases are recorded in the archived story
+			s, err := manifest.LoadStoryFromBranchName(fs, "test-story")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Releases).To(Equal(map[string]string{"one": "2.0.1", "two": "1.5.0"}))
+		})
+
+		It("Should not release dependents whose specifiers can't be updated", func() {
+			// Given one consumes two from a local path
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "version": "2.0.0", "dependencies": {"two": "file:../two"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "commit", "-am", "use a local two")
+
+			// When I release the story
+			Expect(cli.App().Run([]string{"story", "release", "test-story"})).To(Succeed())
+
+			// Then only two is released, and one is left as it was
+			s, err := manifest.LoadStoryFromBranchName(fs, "test-story")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Releases).To(Equal(map[string]string{"two": "1.5.0"}))
+
+			one := node.PackageJSON{}
+			Expect(one.Load(fs, "one")).To(Succeed())
+			Expect(one.Version()).To(Equal("2.0.0"))
+			Expect(one.Dependencies).To(HaveKeyWithValue("two", "file:../two"))
+		})
+
+		It("Should use bumps given as flags", func() {
+			Expect(cli.App().Run([]string{"story", "release", "--major", "two", "test-story"})).To(Succeed())
+
+			s, err := manifest.LoadStoryFromBranchName(fs, "test-story")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Releases).To(HaveKeyWithValue("two", "2.0.0"))
+		})
+
+		It("Should return an error when releasing from a story branch", func() {
+			Expect(cli.App().Run([]string{"story", "create", "another-story"})).To(Succeed())
+
+			err := cli.App().Run([]string{"story", "release", "test-story"})
+			Expect(err).To(Equal(cli.ErrReleaseFromTrunk))
+		})
+	})
+
 	Describe("Add", func() {
 		It("Should add a project to a story", func() {
 			// Given an initialised metarepo with projects and a story
@@ -365,6 +939,49 @@ var _ = Describe("App", func() {
 		})
 	})
 
+	Describe("Bump Dep", func() {
+		It("Should create a story which updates a dependency in every project using it", func() {
+			// Given a project which uses lodash
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"version": "1.0.0", "name": "one", "dependencies": {"lodash": "^4.0.0"}}`), os.FileMode(0666))).To(Succeed())
+
+			command := exec.Command("git", "init")
+			command.Dir = "one"
+			_, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Add(git.AddOpts{Project: "one", Files: []string{"package.json"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Commit(git.CommitOpts{Project: "one", Messages: []string{"initial commit"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I bump lodash
+			Expect(cli.App().Run([]string{"story", "bump-dep", "lodash", "^4.17.21"})).To(Succeed())
+
+			// Then a story is created with the project
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Name).To(Equal("bump-lodash"))
+			Expect(s.Projects).To(HaveKey("one"))
+			Expect(s.Artifacts).To(HaveKeyWithValue("one", true))
+
+			branch, err := git.GetCurrentBranch(fs, "one")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("bump-lodash"))
+
+			// And the dependency is updated without reordering the package.json file
+			b, err := afero.ReadFile(fs, "one/package.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("{\n  \"version\": \"1.0.0\",\n  \"name\": \"one\",\n  \"dependencies\": {\n    \"lodash\": \"^4.17.21\"\n  }\n}\n"))
+		})
+
+		It("Should return an error if a package and range are not given", func() {
+			err := cli.App().Run([]string{"story", "bump-dep", "lodash"})
+			Expect(err).To(Equal(cli.ErrCommandRequiresTwoArguments))
+		})
+	})
+
 	Describe("Remove", func() {
 		It("Should remove a project from the story", func() {
 			// Given an initialised metarepo with projects and a story with a project added
@@ -432,6 +1049,848 @@ var _ = Describe("App", func() {
 		})
 	})
 
+	Describe("Exec", func() {
+		BeforeEach(func() {
+			// Given a story with a project
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			command := exec.Command("git", "init")
+			command.Dir = "one"
+			_, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git"}
+			Expect(s.Write(fs)).To(Succeed())
+		})
+
+		It("Should run a command in each project and stage the changes", func() {
+			// When I run a command which creates a file
+			Expect(cli.App().Run([]string{"story", "exec", "--stage", "--", "sh", "-c", "echo changed > changed.txt"})).To(Succeed())
+
+			// Then the file is staged
+			staged, err := git.StagedFiles("one")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staged).To(Equal([]string{"changed.txt"}))
+		})
+
+		It("Should return an error naming the projects where the command failed", func() {
+			err := cli.App().Run([]string{"story", "exec", "--", "sh", "-c", "exit 1"})
+			Expect(err).To(Equal(cli.ErrCommandFailedInProjects([]string{"one"})))
+		})
+	})
+
+	Describe("Test", func() {
+		BeforeEach(func() {
+			// Given a story where one depends on two, and three depends on nothing
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("three", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "three/package.json", []byte(`{"name": "three"}`), os.FileMode(0666))).To(Succeed())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "git@github.com:test-org/two.git", "three": "git@github.com:test-org/three.git"}
+			s.BlastRadius = map[string][]string{"two": {"one"}}
+			Expect(s.Write(fs)).To(Succeed())
+		})
+
+		It("Should test dependencies before their dependents", func() {
+			// Given test commands which record the order they ran in
+			config := `{"defaults": {"test": "basename $(pwd) >> ../order.txt"}}`
+			Expect(afero.WriteFile(fs, manifest.ConfigFile, []byte(config), os.FileMode(0666))).To(Succeed())
+
+			// When I run the tests
+			Expect(cli.App().Run([]string{"story", "test"})).To(Succeed())
+
+			// Then one is tested after two
+			order, err := afero.ReadFile(fs, "order.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Index(string(order), "two")).To(BeNumerically("<", strings.Index(string(order), "one")))
+			Expect(string(order)).To(ContainSubstring("three"))
+		})
+
+		It("Should skip dependents of failing projects and write a JUnit summary", func() {
+			// Given the tests of two fail
+			config := `{"defaults": {"test": "true"}, "projects": {"two": {"test": "exit 1"}}}`
+			Expect(afero.WriteFile(fs, manifest.ConfigFile, []byte(config), os.FileMode(0666))).To(Succeed())
+
+			// When I run the tests
+			err := cli.App().Run([]string{"story", "test", "--junit", "junit.xml"})
+
+			// Then only two failed
+			Expect(err).To(Equal(cli.ErrCommandFailedInProjects([]string{"two"})))
+
+			// And the summary shows one was skipped
+			junit, err := afero.ReadFile(fs, "junit.xml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(junit)).To(ContainSubstring(`tests="3" failures="1" skipped="1"`))
+			Expect(string(junit)).To(ContainSubstring(`<skipped message="two did not pass">`))
+		})
+	})
+
+	Describe("Build", func() {
+		run := func(dir string, args ...string) string {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		BeforeEach(func() {
+			// Given a story with an artifact and a build command which records each build
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one"}`), os.FileMode(0666))).To(Succeed())
+			config := `{"projects": {"one": {"build": "echo built >> ../builds.txt"}}}`
+			Expect(afero.WriteFile(fs, manifest.ConfigFile, []byte(config), os.FileMode(0666))).To(Succeed())
+
+			run("one", "init")
+			run("one", "add", "--all")
+			run("one", "commit", "-m", "initial commit")
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git"}
+			s.Hashes = map[string]string{"one": run("one", "rev-parse", "HEAD")}
+			s.Artifacts = map[string]bool{"one": true}
+			s.AllProjects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "git@github.com:test-org/two.git"}
+			Expect(s.Write(fs)).To(Succeed())
+		})
+
+		It("Should skip artifacts which have already been built at the same commits", func() {
+			// When I build the story twice
+			Expect(cli.App().Run([]string{"story", "build"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "build"})).To(Succeed())
+
+			// Then the artifact is only built once, and recorded in the cache in .git
+			builds, err := afero.ReadFile(fs, "builds.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(builds)).To(Equal("built\n"))
+
+			exists, err := afero.DirExists(fs, build.CacheDirectory)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			// And it is built again when its hash in the story changes
+			Expect(afero.WriteFile(fs, "one/index.js", []byte("changed"), os.FileMode(0666))).To(Succeed())
+			run("one", "add", "--all")
+			run("one", "commit", "-m", "change")
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Hashes["one"] = run("one", "rev-parse", "HEAD")
+			Expect(s.Write(fs)).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "build"})).To(Succeed())
+
+			builds, err = afero.ReadFile(fs, "builds.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(builds)).To(Equal("built\nbuilt\n"))
+		})
+
+		It("Should not cache builds with uncommitted changes", func() {
+			// Given the artifact has uncommitted changes
+			Expect(afero.WriteFile(fs, "one/index.js", []byte("changed"), os.FileMode(0666))).To(Succeed())
+
+			// When I build the story twice
+			Expect(cli.App().Run([]string{"story", "build"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "build"})).To(Succeed())
+
+			// Then the artifact is built both times
+			builds, err := afero.ReadFile(fs, "builds.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(builds)).To(Equal("built\nbuilt\n"))
+		})
+
+		It("Should return an error when a dependency has not been cloned", func() {
+			// Given the artifact depends on two, which has not been cloned
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"two": "^1.0.0"}}`), os.FileMode(0666))).To(Succeed())
+
+			// When I build the story
+			err := cli.App().Run([]string{"story", "build"})
+
+			// Then it returns an error
+			Expect(err).To(Equal(cli.ErrDependencyNotCloned("one", "two")))
+		})
+	})
+
+	Describe("CI Matrix", func() {
+		BeforeEach(func() {
+			// Given an archived story manifest
+			Expect(fs.MkdirAll("story", os.FileMode(0700))).To(Succeed())
+			archived := `{"story": "test-story", "artifacts": {"one": true}, "hashes": {"one": "1111111111"}}`
+			Expect(afero.WriteFile(fs, "story/test-story.json", []byte(archived), os.FileMode(0666))).To(Succeed())
+		})
+
+		It("Should generate a matrix from an archived story manifest on trunk", func() {
+			Expect(cli.App().Run([]string{"story", "ci", "matrix", "--format", "github-actions", "--from-manifest", "test-story"})).To(Succeed())
+		})
+
+		It("Should return an error for unsupported formats", func() {
+			err := cli.App().Run([]string{"story", "ci", "matrix", "--format", "circleci", "--from-manifest", "test-story"})
+			Expect(err).To(Equal(cli.ErrUnsupportedFormat("circleci")))
+		})
+
+		It("Should return an error on trunk without a manifest", func() {
+			err := cli.App().Run([]string{"story", "ci", "matrix"})
+			Expect(err).To(Equal(cli.ErrNotWorkingOnAStory))
+		})
+	})
+
+	Describe("CI Checkout", func() {
+		run := func(dir string, args ...string) string {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		It("Should clone story projects at their hashes and the dependencies of artifacts on trunk", func() {
+			// Given a remote for an artifact one, which depends on two
+			Expect(fs.MkdirAll("external/one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "external/one/package.json", []byte(`{"name": "one", "dependencies": {"@test-org/two": "^1.0.0"}}`), os.FileMode(0666))).To(Succeed())
+			run("external/one", "init")
+			run("external/one", "checkout", "-b", "master")
+			run("external/one", "add", "package.json")
+			run("external/one", "commit", "-m", "initial commit")
+			hash := run("external/one", "rev-parse", "HEAD")
+
+			// And a remote for two
+			Expect(fs.MkdirAll("external/two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "external/two/package.json", []byte(`{"name": "@test-org/two"}`), os.FileMode(0666))).To(Succeed())
+			run("external/two", "init")
+			run("external/two", "checkout", "-b", "master")
+			run("external/two", "add", "package.json")
+			run("external/two", "commit", "-m", "initial commit")
+
+			// And a story manifest where one is pinned to a hash
+			wd, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+			s := manifest.Story{
+				Name:          "test-story",
+				Orgranisation: "test-org",
+				Projects:      map[string]string{"one": "file://" + wd + "/external/one"},
+				Hashes:        map[string]string{"one": hash},
+				Artifacts:     map[string]bool{"one": true},
+				AllProjects:   map[string]string{"one": "file://" + wd + "/external/one", "two": "file://" + wd + "/external/two"},
+			}
+			Expect(fs.MkdirAll("story", os.FileMode(0700))).To(Succeed())
+			Expect(s.WriteToLocation(fs, "story/test-story.json")).To(Succeed())
+
+			// When I check out the story
+			Expect(cli.App().Run([]string{"story", "ci", "checkout", "--from-manifest", "test-story"})).To(Succeed())
+
+			// Then one is checked out at its hash
+			head, err := git.HeadCommit("one")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(head).To(Equal(hash))
+
+			// And two is cloned as a dependency of one
+			exists, err := afero.Exists(fs, "two/package.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+	})
+
+	Describe("Deploy Manifest", func() {
+		It("Should describe the cloned artifacts of trunk from their current commits", func() {
+			// Given the artifacts of trunk, which are never set, where one is cloned and two
+			// has not been cloned
+			meta := `{"organisation": "test-org", "artifacts": {"one": false, "two": false}, "projects": {"one": "git@github.com:test-org/one.git", "two": "external/remote"}}`
+			Expect(afero.WriteFile(fs, ".meta", []byte(meta), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			for _, args := range [][]string{{"init"}, {"commit", "--allow-empty", "-m", "initial commit"}} {
+				command := exec.Command("git", args...)
+				command.Dir = "one"
+				out, err := command.CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(out))
+			}
+
+			// When I generate a deploy manifest on trunk
+			var err error
+			out := captureStdout(func() {
+				err = cli.App().Run([]string{"story", "deploy-manifest", "--type", "kustomize"})
+			})
+
+			// Then it succeeds with only the cloned artifact one
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("name: one"))
+			Expect(out).NotTo(ContainSubstring("name: two"))
+		})
+
+		It("Should describe the artifacts of a story from their hashes", func() {
+			// Given a story where one is pinned to a hash
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git"}
+			s.Hashes = map[string]string{"one": "1111111111"}
+			s.Artifacts = map[string]bool{"one": true}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I generate a deploy manifest, then it succeeds without one being cloned
+			Expect(cli.App().Run([]string{"story", "deploy-manifest", "--format", "json", "--image-registry", "registry.test-org.com"})).To(Succeed())
+		})
+
+		It("Should return an error for unsupported descriptor types", func() {
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			err := cli.App().Run([]string{"story", "deploy-manifest", "--type", "nomad"})
+			Expect(err).To(Equal(cli.ErrUnsupportedDescriptorType("nomad")))
+		})
+	})
+
+	Describe("Bundle", func() {
+		run := func(dir string, args ...string) string {
+			command := exec.Command("git", args...)
+			command.Dir = dir
+			out, err := command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		It("Should bundle an artifact with its private dependencies at their pinned hashes", func() {
+			// Given a library two pinned to its first commit, which has changed since
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "@test-org/two", "version": "1.4.0"}`), os.FileMode(0666))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/index.js", []byte("pinned"), os.FileMode(0666))).To(Succeed())
+			run("two", "init")
+			run("two", "add", "--all")
+			run("two", "commit", "-m", "initial commit")
+			hash := run("two", "rev-parse", "HEAD")
+			Expect(afero.WriteFile(fs, "two/index.js", []byte("changed"), os.FileMode(0666))).To(Succeed())
+
+			// And an artifact one which depends on two over git
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"@test-org/two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "init")
+			run("one", "add", "--all")
+			run("one", "commit", "-m", "initial commit")
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote"}
+			s.Hashes = map[string]string{"two": hash}
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I bundle one
+			Expect(cli.App().Run([]string{"story", "bundle", "one"})).To(Succeed())
+
+			// Then the build context points one at the vendored tarball of two
+			archive, err := afero.ReadFile(fs, "one.tar.gz")
+			Expect(err).NotTo(HaveOccurred())
+			files := untar(archive)
+			Expect(files["package.json"]).To(ContainSubstring(`"@test-org/two": "file:.story-bundle/test-org-two.tgz"`))
+
+			// And the vendored tarball contains two at its pinned hash
+			vendored := untar([]byte(files[".story-bundle/test-org-two.tgz"]))
+			Expect(vendored["package/index.js"]).To(Equal("pinned"))
+		})
+
+		It("Should not bundle dev dependencies", func() {
+			// Given an artifact one which only depends on two as a dev dependency
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "two/package.json", []byte(`{"name": "@test-org/two", "version": "1.4.0"}`), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "devDependencies": {"@test-org/two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "init")
+			run("one", "add", "--all")
+			run("one", "commit", "-m", "initial commit")
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// When I bundle one
+			Expect(cli.App().Run([]string{"story", "bundle", "one"})).To(Succeed())
+
+			// Then two is not vendored into the build context
+			archive, err := afero.ReadFile(fs, "one.tar.gz")
+			Expect(err).NotTo(HaveOccurred())
+			files := untar(archive)
+			Expect(files).NotTo(HaveKey(".story-bundle/test-org-two.tgz"))
+			Expect(files["package.json"]).To(ContainSubstring(`"@test-org/two": "git+ssh://git@github.com:test-org/two.git#test-story"`))
+		})
+
+		It("Should return an error when a dependency has not been cloned", func() {
+			// Given an artifact one which depends on two, which has not been cloned
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one", "dependencies": {"@test-org/two": "git+ssh://git@github.com:test-org/two.git#test-story"}}`), os.FileMode(0666))).To(Succeed())
+			run("one", "init")
+			run("one", "add", "--all")
+			run("one", "commit", "-m", "initial commit")
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Orgranisation = "test-org"
+			Expect(s.Write(fs)).To(Succeed())
+
+			// When I bundle one
+			err = cli.App().Run([]string{"story", "bundle", "one"})
+
+			// Then it returns an error
+			Expect(err).To(Equal(cli.ErrDependencyNotCloned("one", "two")))
+		})
+
+		It("Should return an error for projects which are not artifacts", func() {
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			err := cli.App().Run([]string{"story", "bundle", "two"})
+			Expect(err).To(Equal(cli.ErrNotAnArtifact("two")))
+		})
+	})
+
+	Describe("Env", func() {
+		It("Should export variables on trunk", func() {
+			Expect(cli.App().Run([]string{"story", "env"})).To(Succeed())
+		})
+
+		It("Should export variables on a story", func() {
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "env", "--format", "dotenv"})).To(Succeed())
+		})
+
+		It("Should return an error for unsupported formats", func() {
+			err := cli.App().Run([]string{"story", "env", "--format", "fish"})
+			Expect(err).To(Equal(cli.ErrUnsupportedFormat("fish")))
+		})
+	})
+
+	Describe("Output", func() {
+		BeforeEach(func() {
+			// Given a story where one is changed and is in the blast radius of two
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"two": "external/remote", "one": "git@github.com:test-org/one.git"}
+			s.Hashes = map[string]string{"one": "1111111111"}
+			s.BlastRadius = map[string][]string{"two": {"one"}}
+			s.Artifacts = map[string]bool{"one": true}
+			Expect(s.Write(fs)).To(Succeed())
+		})
+
+		It("Should print sorted records for read commands", func() {
+			var records []cli.Record
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "json", "list"})).To(Succeed())
+			})
+
+			Expect(json.Unmarshal([]byte(out), &records)).To(Succeed())
+			Expect(records).To(Equal([]cli.Record{
+				{Project: "one", URL: "git@github.com:test-org/one.git", Hash: "1111111111", Artifact: true, BlastRadius: []string{"two"}},
+				{Project: "two", URL: "external/remote"},
+			}))
+		})
+
+		It("Should print project names in text mode", func() {
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "list"})).To(Succeed())
+			})
+
+			Expect(out).To(Equal("one\ntwo\n"))
+		})
+
+		It("Should print a result for each project of write commands", func() {
+			// Given both projects are cloned
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			Expect(fs.MkdirAll("two", os.FileMode(0700))).To(Succeed())
+
+			var results []cli.Result
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "yaml", "exec", "--", "sh", "-c", "basename $(pwd)"})).To(Succeed())
+			})
+
+			Expect(out).To(HavePrefix("- project: one\n  output: |\n    one\n"))
+			Expect(json.Unmarshal([]byte(captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "json", "exec", "--", "sh", "-c", "exit 2"})).NotTo(Succeed())
+			})), &results)).To(Succeed())
+			Expect(results).To(Equal([]cli.Result{{Project: "one", ExitCode: 2}, {Project: "two", ExitCode: 2}}))
+		})
+
+		It("Should print records for commands which are not lists of projects", func() {
+			// When I explain a project which has been added to the story
+			var explanation cli.Explanation
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "json", "explain", "two"})).To(Succeed())
+			})
+
+			// Then the explanation is printed as a record
+			Expect(json.Unmarshal([]byte(out), &explanation)).To(Succeed())
+			Expect(explanation).To(Equal(cli.Explanation{Project: "two", Added: true, Paths: []cli.Path{}}))
+
+			// And checks without problems print empty records instead of messages
+			Expect(captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "json", "verify"})).To(Succeed())
+			})).To(Equal("[]\n"))
+
+			Expect(captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "yaml", "cycles"})).To(Succeed())
+			})).To(Equal("[]\n"))
+		})
+
+		It("Should print records instead of the format of a command", func() {
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "yaml", "env"})).To(Succeed())
+			})
+
+			Expect(out).To(ContainSubstring("STORY_NAME: test-story\n"))
+		})
+
+		It("Should print the changes to artifacts when refreshing the blast radius", func() {
+			var changes cli.ArtifactChanges
+			out := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--output", "json", "blastradius", "--refresh"})).To(Succeed())
+			})
+
+			Expect(json.Unmarshal([]byte(out), &changes)).To(Succeed())
+		})
+
+		It("Should return an error when a command format is set with structured output", func() {
+			err := cli.App().Run([]string{"story", "--output", "json", "env", "--format", "dotenv"})
+			Expect(err).To(Equal(cli.ErrFormatConflictsWithOutput("dotenv", "json")))
+		})
+
+		It("Should return an error for unsupported formats", func() {
+			err := cli.App().Run([]string{"story", "--output", "xml", "list"})
+			Expect(err).To(Equal(cli.ErrUnsupportedFormat("xml")))
+		})
+	})
+
+	Describe("Keep Going", func() {
+		BeforeEach(func() {
+			// Given a story with two projects which can't be checked out on trunk
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			s.Projects = map[string]string{"one": "git@github.com:test-org/one.git", "two": "external/remote"}
+			Expect(s.Write(fs)).To(Succeed())
+		})
+
+		It("Should stop at the first failing project by default", func() {
+			err := cli.App().Run([]string{"story", "reset"})
+			Expect(err).To(HaveOccurred())
+			Expect(cli.ExitCode(err)).To(Equal(1))
+
+			// And the metarepo is still on the story branch
+			branch, err := git.GetCurrentBranch(fs, ".")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("test-story"))
+		})
+
+		It("Should attempt every project and report a partial failure", func() {
+			err := cli.App().Run([]string{"story", "--keep-going", "reset"})
+
+			// Then both projects failed
+			Expect(err).To(BeAssignableToTypeOf(&cli.ErrPartialFailure{}))
+			partial := err.(*cli.ErrPartialFailure)
+			Expect(partial.Errors).To(HaveLen(2))
+			Expect(partial.Errors[0].Operation).To(Equal("checkout"))
+			Expect(cli.ExitCode(err)).To(Equal(cli.ExitCodePartialFailure))
+
+			// And the metarepo was still reset
+			branch, err := git.GetCurrentBranch(fs, ".")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("master"))
+		})
+
+		It("Should report a partial failure returned along with the error of a command", func() {
+			// Given the error of a command and the partial failure of the projects it attempted
+			partial := &cli.ErrPartialFailure{Errors: []*cli.ProjectError{{Project: "one", Operation: "checkout", Err: cli.ErrNotWorkingOnAStory}}}
+			err := urfave.NewMultiError(cli.ErrNotWorkingOnAStory, partial)
+
+			// Then the exit code is still the partial failure exit code
+			Expect(cli.ExitCode(err)).To(Equal(cli.ExitCodePartialFailure))
+			Expect(cli.ExitCode(urfave.NewMultiError(cli.ErrNotWorkingOnAStory))).To(Equal(1))
+		})
+	})
+
+	Describe("Dry Run", func() {
+		BeforeEach(func() {
+			// Given an initialised metarepo with a project and a story with the project added
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			p := node.PackageJSON{}
+			b, err := json.Marshal(p)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(afero.WriteFile(fs, "one/package.json", b, os.FileMode(0666))).To(Succeed())
+
+			command := exec.Command("git", "init")
+			command.Dir = "one"
+			_, err = command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Add(git.AddOpts{Project: "one", Files: []string{"package.json"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Commit(git.CommitOpts{Project: "one", Messages: []string{"initial commit"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "add", "one"})).To(Succeed())
+		})
+
+		It("Should print the planned operations and changes without making them", func() {
+			// When I remove a project with --dry-run
+			stdout := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "--dry-run", "remove", "one"})).To(Succeed())
+			})
+
+			// Then the git operations are printed per project
+			Expect(stdout).To(ContainSubstring("dry run, no changes were made\none\n  git checkout master\n"))
+
+			// And the change to the story is printed as a diff
+			Expect(stdout).To(ContainSubstring("--- a/.meta\n+++ b/.meta\n"))
+			Expect(stdout).To(ContainSubstring(`-    "one": "git@github.com:test-org/one.git"`))
+
+			// And the project is still in the story and on the story branch
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Projects).To(HaveKey("one"))
+
+			branch, err := git.GetCurrentBranch(fs, "one")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("test-story"))
+		})
+
+		It("Should return an error for commands which run arbitrary commands", func() {
+			err := cli.App().Run([]string{"story", "--dry-run", "exec", "--", "npm", "install"})
+			Expect(err).To(Equal(cli.ErrDryRunNotSupported("exec")))
+		})
+	})
+
+	Describe("Journal", func() {
+		It("Should undo creating a story", func() {
+			// Given an initialised metarepo where a story was created
+			trunkMeta, err := afero.ReadFile(fs, ".meta")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// Then the command is in the journal
+			stdout := captureStdout(func() {
+				Expect(cli.App().Run([]string{"story", "journal"})).To(Succeed())
+			})
+
+			Expect(stdout).To(ContainSubstring("create test-story  test, .meta"))
+
+			// When I undo it
+			Expect(cli.App().Run([]string{"story", "undo"})).To(Succeed())
+
+			// Then the metarepo is back on trunk with the trunk .meta file
+			branch, err := git.GetCurrentBranch(fs, ".")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("master"))
+			Expect(afero.ReadFile(fs, ".meta")).To(Equal(trunkMeta))
+
+			// And the story branch is deleted
+			Expect(git.Branches("")).NotTo(HaveKey("test-story"))
+
+			// And there is nothing left to undo
+			Expect(cli.App().Run([]string{"story", "undo"})).To(Equal(cli.ErrNothingToUndo))
+		})
+
+		It("Should undo removing a project from a story", func() {
+			// Given a story with a project which was removed
+			Expect(fs.MkdirAll("one", os.FileMode(0700))).To(Succeed())
+			b, err := json.Marshal(node.PackageJSON{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(afero.WriteFile(fs, "one/package.json", b, os.FileMode(0666))).To(Succeed())
+
+			command := exec.Command("git", "init")
+			command.Dir = "one"
+			_, err = command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Add(git.AddOpts{Project: "one", Files: []string{"package.json"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Commit(git.CommitOpts{Project: "one", Messages: []string{"initial commit"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "add", "one"})).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "remove", "one"})).To(Succeed())
+
+			// When I undo the removal
+			Expect(cli.App().Run([]string{"story", "undo"})).To(Succeed())
+
+			// Then the project is in the story again and on the story branch
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Projects).To(HaveKey("one"))
+
+			branch, err := git.GetCurrentBranch(fs, "one")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("test-story"))
+		})
+
+		It("Should remove projects cloned by the command when undoing it", func() {
+			// Given a story and a remote project two with a commit
+			Expect(fs.MkdirAll("external/two", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "external/two/package.json", []byte(`{"name": "two"}`), os.FileMode(0666))).To(Succeed())
+			for _, args := range [][]string{{"init"}, {"add", "package.json"}, {"commit", "-m", "initial commit"}} {
+				command := exec.Command("git", args...)
+				command.Dir = "external/two"
+				out, err := command.CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(out))
+			}
+
+			m, err := manifest.LoadMetaOnTrunk(fs)
+			Expect(err).NotTo(HaveOccurred())
+			m.Projects["two"] = "external/two"
+			Expect(m.Write(fs)).To(Succeed())
+			_, err = git.Add(git.AddOpts{Files: []string{".meta"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = git.Commit(git.CommitOpts{Messages: []string{"add two"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// And two was cloned with the --ci flag
+			Expect(cli.App().Run([]string{"story", "add", "two", "--ci"})).To(Succeed())
+			Expect(afero.DirExists(fs, "two")).To(BeTrue())
+
+			// When I undo the command
+			Expect(cli.App().Run([]string{"story", "undo"})).To(Succeed())
+
+			// Then the clone is removed
+			Expect(afero.DirExists(fs, "two")).To(BeFalse())
+		})
+
+		It("Should undo a command when the files it changed have uncommitted changes", func() {
+			// Given a story which was created, with its .meta committed and changed again
+			trunkMeta, err := afero.ReadFile(fs, ".meta")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			_, err = git.Add(git.AddOpts{Files: []string{".meta"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = git.Commit(git.CommitOpts{Messages: []string{"create test-story"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(afero.WriteFile(fs, ".meta", []byte("{}"), os.FileMode(0666))).To(Succeed())
+
+			// When I force undoing it
+			Expect(cli.App().Run([]string{"story", "undo", "--force"})).To(Succeed())
+
+			// Then the metarepo is back on trunk with the trunk .meta file
+			branch, err := git.GetCurrentBranch(fs, ".")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("master"))
+			Expect(afero.ReadFile(fs, ".meta")).To(Equal(trunkMeta))
+		})
+
+		It("Should return an error if a repository changed since the command", func() {
+			// Given a story which was created and then left
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+			_, err := git.CheckoutBranch(git.CheckoutBranchOpts{Branch: "master"})
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I undo the command
+			err = cli.App().Run([]string{"story", "undo"})
+
+			// Then it returns an error
+			Expect(err).To(Equal(cli.ErrJournalEntryOutdated("create test-story", []string{"test"})))
+		})
+	})
+
+	Describe("Metarepo Discovery", func() {
+		var root string
+
+		BeforeEach(func() {
+			var err error
+			root, err = os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.Chdir(root)).To(Succeed())
+		})
+
+		It("Should default to the project it is run from inside", func() {
+			// Given a story and a project with a subdirectory
+			Expect(fs.MkdirAll("one/src", os.FileMode(0700))).To(Succeed())
+			b, err := json.Marshal(node.PackageJSON{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(afero.WriteFile(fs, "one/package.json", b, os.FileMode(0666))).To(Succeed())
+
+			command := exec.Command("git", "init")
+			command.Dir = "one"
+			_, err = command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Add(git.AddOpts{Project: "one", Files: []string{"package.json"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.Commit(git.CommitOpts{Project: "one", Messages: []string{"initial commit"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// When I add a project without naming it from inside the project
+			Expect(os.Chdir("one/src")).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "add"})).To(Succeed())
+
+			// Then the project is added to the story in the metarepo
+			Expect(os.Chdir(root)).To(Succeed())
+			s, err := manifest.LoadStory(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Projects).To(HaveKey("one"))
+		})
+
+		It("Should resolve relative paths in flags against the directory it is run from", func() {
+			// Given a story and an artifact with a subdirectory
+			Expect(fs.MkdirAll("one/src", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "one/package.json", []byte(`{"name": "one"}`), os.FileMode(0666))).To(Succeed())
+			for _, args := range [][]string{{"init"}, {"add", "package.json"}, {"commit", "-m", "initial commit"}} {
+				command := exec.Command("git", args...)
+				command.Dir = "one"
+				out, err := command.CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(out))
+			}
+
+			Expect(cli.App().Run([]string{"story", "create", "test-story"})).To(Succeed())
+
+			// When I bundle the artifact to a relative path from inside it
+			Expect(os.Chdir("one/src")).To(Succeed())
+			Expect(cli.App().Run([]string{"story", "bundle", "--file", "context.tar.gz"})).To(Succeed())
+
+			// Then the bundle is written relative to where I ran the command
+			Expect(os.Chdir(root)).To(Succeed())
+			Expect(afero.Exists(fs, "one/src/context.tar.gz")).To(BeTrue())
+			Expect(afero.Exists(fs, "context.tar.gz")).To(BeFalse())
+		})
+
+		It("Should use the metarepo given with --metarepo", func() {
+			// Given I am outside of the metarepo
+			Expect(os.Chdir("..")).To(Succeed())
+
+			// When I create a story in the metarepo
+			Expect(cli.App().Run([]string{"story", "--metarepo", "test", "create", "test-story"})).To(Succeed())
+
+			// Then the metarepo is on the story branch
+			branch, err := git.GetCurrentBranch(fs, root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("test-story"))
+		})
+
+		It("Should return an error outside of a metarepo", func() {
+			// Given a directory without a metarepo above it
+			outside, err := ioutil.TempDir("", "story")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(outside)
+			Expect(os.Chdir(outside)).To(Succeed())
+
+			wd, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I run a command, Then it returns an error
+			Expect(cli.App().Run([]string{"story", "list"})).To(Equal(cli.ErrMetarepoNotFound(wd)))
+		})
+	})
+
 	Describe("Commit", func() {
 		It("Should commit in changed repos, and commit a storyhash in the metarepo", func() {
 			// Given an initialised metarepo with projects and a story with a project added
@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"fmt"
-
 	"github.com/LGUG2Z/story/manifest"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
@@ -26,13 +24,14 @@ func ArtifactsCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
+			var artifacts []string
 			for project := range story.Artifacts {
 				if story.Artifacts[project] {
-					fmt.Println(project)
+					artifacts = append(artifacts, project)
 				}
 			}
 
-			return nil
+			return printRecords(storyRecords(story, artifacts))
 		},
 	}
 }
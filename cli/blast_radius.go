@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"fmt"
-
 	"github.com/LGUG2Z/story/manifest"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
@@ -12,6 +10,10 @@ func BlastRadiusCmd(fs afero.Fs) cli.Command {
 	return cli.Command{
 		Name:  "blastradius",
 		Usage: "Shows a list of current story's blast radius",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "refresh", Usage: "Recalculate the blast radius and artifacts of the current story"},
+			cli.IntFlag{Name: "depth", Usage: "limit how many levels of dependents are included in the blast radius"},
+		},
 		Action: func(c *cli.Context) error {
 			if !isStory {
 				return ErrNotWorkingOnAStory
@@ -26,21 +28,32 @@ func BlastRadiusCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
+			if c.Bool("refresh") {
+				gained, lost, err := refreshBlastRadius(fs, story, blastRadiusDepth(c, story))
+				if err != nil {
+					return err
+				}
+
+				if err := story.Write(fs); err != nil {
+					return err
+				}
+
+				return printArtifactChanges(gained, lost)
+			}
+
 			var brMap = make(map[string]bool)
+			var projects []string
 
 			for _, br := range story.BlastRadius {
 				for _, p := range br {
 					if !brMap[p] {
 						brMap[p] = true
+						projects = append(projects, p)
 					}
 				}
 			}
 
-			for project := range brMap {
-				fmt.Println(project)
-			}
-
-			return nil
+			return printRecords(storyRecords(story, projects))
 		},
 	}
 }
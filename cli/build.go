@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LGUG2Z/story/build"
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/runner"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func BuildCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "build",
+		Usage: "Builds the artifacts of the current story which have not already been built",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "cache-dir", Value: build.CacheDirectory, EnvVar: "STORY_BUILD_CACHE", Usage: "directory in which completed builds are recorded"},
+			cli.IntFlag{Name: "parallel", Usage: "maximum number of artifacts to build at once (default: number of CPUs)"},
+		},
+		Action: func(c *cli.Context) error {
+			if dryrun.Enabled() {
+				return ErrDryRunNotSupported("build")
+			}
+
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			var artifacts []string
+			for project, artifact := range story.Artifacts {
+				if artifact {
+					artifacts = append(artifacts, project)
+				}
+			}
+
+			sort.Strings(artifacts)
+
+			cache := build.NewCache(fs, pathFlag(c, "cache-dir"))
+			keys := make(map[string]string)
+			commits := make(map[string]map[string]string)
+
+			var tasks []runner.Task
+			for _, artifact := range artifacts {
+				cloned, err := afero.DirExists(fs, artifact)
+				if err != nil {
+					return err
+				}
+
+				if !cloned {
+					color.Yellow("skipping %s as it has not been cloned", artifact)
+					continue
+				}
+
+				commits[artifact], err = buildCommits(fs, story, g, artifact)
+				if err != nil {
+					return err
+				}
+
+				// Uncommitted changes aren't part of the key, so those builds are never cached
+				dirty, err := dirtyProjects(commits[artifact])
+				if err != nil {
+					return err
+				}
+
+				if len(dirty) > 0 {
+					color.Yellow("building %s without caching as %s has uncommitted changes", artifact, strings.Join(dirty, ", "))
+					tasks = append(tasks, runner.Task{Project: artifact, Command: config.BuildCommand(artifact)})
+					continue
+				}
+
+				keys[artifact] = build.Key(artifact, commits[artifact])
+				cached, err := cache.Has(keys[artifact])
+				if err != nil {
+					return err
+				}
+
+				if cached {
+					color.Yellow("skipping %s as %s has already been built", artifact, keys[artifact][:12])
+					continue
+				}
+
+				tasks = append(tasks, runner.Task{Project: artifact, Command: config.BuildCommand(artifact)})
+			}
+
+			var failed []string
+			for _, result := range runner.Run(tasks, c.Int("parallel")) {
+				printRunnerResult(result)
+
+				if result.Failed() {
+					failed = append(failed, result.Project)
+					continue
+				}
+
+				if _, ok := keys[result.Project]; !ok {
+					continue
+				}
+
+				entry := build.Entry{Project: result.Project, Commits: commits[result.Project], Built: time.Now()}
+				if err := cache.Put(keys[result.Project], entry); err != nil {
+					return err
+				}
+			}
+
+			return failedInProjects("build", failed)
+		},
+	}
+}
+
+// buildCommits returns the commits of an artifact and everything it transitively depends on,
+// which are their hashes in the story or their checked out commits if they aren't pinned,
+// returning an error if any of its dependencies have not been cloned
+func buildCommits(fs afero.Fs, story *manifest.Story, g *graph.Graph, artifact string) (map[string]string, error) {
+	commits := make(map[string]string)
+	for _, project := range append(g.TransitiveDependencies(artifact, 0), artifact) {
+		// Dependencies which aren't cloned aren't in the graph, so they are found from manifests
+		dependencies, err := projectDependencies(fs, story, project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dependency := range dependencies {
+			cloned, err := afero.DirExists(fs, dependency)
+			if err != nil {
+				return nil, err
+			}
+
+			if !cloned {
+				return nil, ErrDependencyNotCloned(artifact, dependency)
+			}
+		}
+
+		hash, ok := story.Hashes[project]
+		if !ok {
+			if hash, err = git.HeadCommit(project); err != nil {
+				return nil, err
+			}
+		}
+
+		commits[project] = hash
+	}
+
+	return commits, nil
+}
+
+// dirtyProjects returns the sorted projects of a build which have uncommitted changes
+func dirtyProjects(commits map[string]string) ([]string, error) {
+	var dirty []string
+	for project := range commits {
+		changed, err := git.HasChanges(project)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			dirty = append(dirty, project)
+		}
+	}
+
+	sort.Strings(dirty)
+
+	return dirty, nil
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/node"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+var nonBranchCharacters = regexp.MustCompile(`[^0-9A-Za-z._-]+`)
+
+func BumpDepCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:      "bump-dep",
+		Usage:     "Updates a third-party dependency in every project which uses it",
+		ArgsUsage: "<package> <range>",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "story", Usage: "name of the story to create when not already working on a story (default: bump-<package>)"},
+			cli.IntFlag{Name: "depth", Usage: "limit how many levels of dependents are included in the blast radius"},
+		},
+		Action: func(c *cli.Context) error {
+			if len(c.Args()) != 2 {
+				return ErrCommandRequiresTwoArguments
+			}
+
+			pkg, specifier := c.Args().Get(0), c.Args().Get(1)
+
+			var story *manifest.Story
+			var err error
+			if isStory {
+				story, err = manifest.LoadStory(fs)
+			} else {
+				name := c.String("story")
+				if name == "" {
+					name = fmt.Sprintf("bump-%s", strings.Trim(nonBranchCharacters.ReplaceAllString(pkg, "-"), "-"))
+				}
+
+				story, err = createStory(fs, name)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			projects, err := projectsDeclaring(fs, story, pkg)
+			if err != nil {
+				return err
+			}
+
+			// Projects already in the story are on the story branch
+			var toAdd []string
+			for _, project := range projects {
+				if _, exists := story.Projects[project]; !exists {
+					toAdd = append(toAdd, project)
+				}
+			}
+
+			if len(toAdd) > 0 {
+				if err := addProjects(fs, story, toAdd, blastRadiusDepth(c, story)); err != nil {
+					return err
+				}
+			}
+
+			for _, project := range projects {
+				if ignore[project] {
+					continue
+				}
+
+				p := node.PackageJSON{}
+				if err := p.Load(fs, project); err != nil {
+					if err := keepGoingOn(project, "bump-dep", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				previous, _ := p.SetDependency(pkg, specifier)
+				if err := p.Write(fs, project); err != nil {
+					if err := keepGoingOn(project, "bump-dep", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				printGitOutput(fmt.Sprintf("%s updated from %s to %s", pkg, previous, specifier), project)
+			}
+
+			return nil
+		},
+	}
+}
+
+// projectsDeclaring returns the node projects in the metarepo which declare a package
+// as a dependency or a dev dependency
+func projectsDeclaring(fs afero.Fs, story *manifest.Story, pkg string) ([]string, error) {
+	g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []string
+	for _, project := range g.Projects {
+		if _, exists := story.AllProjects[project]; !exists {
+			continue
+		}
+
+		a, err := ecosystem.Load(fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.Ecosystem() != "node" {
+			continue
+		}
+
+		for _, d := range a.Dependencies() {
+			if d.Name == pkg {
+				projects = append(projects, project)
+				break
+			}
+		}
+	}
+
+	sort.Strings(projects)
+
+	return projects, nil
+}
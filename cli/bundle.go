@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/LGUG2Z/story/bundle"
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/node"
+	"github.com/LGUG2Z/story/registry"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func BundleCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:      "bundle",
+		Usage:     "Bundles an artifact and its private dependencies into a build context",
+		ArgsUsage: "<artifact>",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "file", Usage: "file to write the build context to (default: <artifact>.tar.gz)"},
+		},
+		Action: func(c *cli.Context) error {
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			args := projectArgs(c)
+			if len(args) == 0 {
+				return ErrCommandRequiresAnArgument
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			artifact := args[0]
+			if _, ok := story.Artifacts[artifact]; !ok {
+				return ErrNotAnArtifact(artifact)
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			// Dev dependencies are not installed in the build context, so only the projects
+			// needed at runtime are bundled
+			var dependencies []string
+			for _, dependency := range g.TransitiveDependenciesInSection(artifact, graph.Dependencies, 0) {
+				a, err := ecosystem.Detect(fs, dependency)
+				if err != nil {
+					return err
+				}
+
+				if a.Ecosystem() == "node" {
+					dependencies = append(dependencies, dependency)
+				}
+			}
+
+			// Dependencies which aren't cloned aren't in the graph and couldn't be bundled, so
+			// they are found from manifests
+			for _, project := range append(dependencies, artifact) {
+				required, err := projectDependencies(fs, story, project, graph.Dependencies)
+				if err != nil {
+					return err
+				}
+
+				for _, dependency := range required {
+					cloned, err := afero.DirExists(fs, dependency)
+					if err != nil {
+						return err
+					}
+
+					if !cloned {
+						return ErrDependencyNotCloned(artifact, dependency)
+					}
+				}
+			}
+
+			// Every project is bundled from its pinned commit rather than the working tree
+			checkouts := afero.NewMemMapFs()
+			commits := make(map[string]string)
+			for _, project := range append(dependencies, artifact) {
+				commit, ok := story.Hashes[project]
+				if !ok {
+					if commit, err = git.HeadCommit(project); err != nil {
+						return err
+					}
+				}
+
+				commits[project] = commit
+
+				archive, err := git.Archive(project, commit)
+				if err != nil {
+					return err
+				}
+
+				if err := bundle.Extract(checkouts, project, archive); err != nil {
+					return err
+				}
+			}
+
+			packageJSONs := make(map[string]*node.PackageJSON)
+			for _, project := range append(dependencies, artifact) {
+				p := &node.PackageJSON{}
+				if err := p.Load(checkouts, project); err != nil {
+					return err
+				}
+
+				packageJSONs[project] = p
+			}
+
+			// Dependencies are pointed at the exact versions of each other, which npm resolves
+			// to the vendored tarballs installed by the artifact
+			vendored := make(map[string][]byte)
+			for _, dependency := range dependencies {
+				p := packageJSONs[dependency]
+				for _, e := range g.Dependencies(dependency) {
+					if d, ok := packageJSONs[e.To]; ok && e.Section == graph.Dependencies {
+						p.SetDependency(g.Packages[e.To], d.Version())
+					}
+				}
+
+				b, err := p.Marshal()
+				if err != nil {
+					return err
+				}
+
+				tarball, err := registry.Pack(dependency, commits[dependency], b)
+				if err != nil {
+					return err
+				}
+
+				vendored[bundle.Tarball(g.Packages[dependency])] = tarball
+				printGitOutput(fmt.Sprintf("packed %s@%s", g.Packages[dependency], p.Version()), dependency)
+			}
+
+			// The artifact installs every vendored tarball directly, including transitive dependencies
+			p := packageJSONs[artifact]
+			for _, dependency := range dependencies {
+				if _, ok := p.SetDependency(g.Packages[dependency], bundle.Specifier(g.Packages[dependency])); !ok {
+					if p.Dependencies == nil {
+						p.Dependencies = make(map[string]string)
+					}
+
+					p.Dependencies[g.Packages[dependency]] = bundle.Specifier(g.Packages[dependency])
+				}
+			}
+
+			b, err := p.Marshal()
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := bundle.Write(&buf, checkouts, artifact, b, vendored); err != nil {
+				return err
+			}
+
+			file := pathFlag(c, "file")
+			if file == "" {
+				file = fmt.Sprintf("%s.tar.gz", artifact)
+			}
+
+			if err := afero.WriteFile(fs, file, buf.Bytes(), os.FileMode(0666)); err != nil {
+				return err
+			}
+
+			printGitOutput(fmt.Sprintf("bundled to %s", file), artifact)
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/ci"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func CICmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "ci",
+		Usage: "Helpers for building stories in continuous integration",
+		Subcommands: []cli.Command{
+			ciMatrixCmd(fs),
+			ciCheckoutCmd(fs),
+		},
+	}
+}
+
+func ciMatrixCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "matrix",
+		Usage: "Shows a build matrix of the artifacts of the current story",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "format", Value: "json", Usage: "Output format (github-actions, gitlab, json)"},
+			cli.StringFlag{Name: "from-manifest", Usage: "use the archived manifest of a story instead of the current story"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			format, err := commandFormat(c)
+			if err != nil {
+				return err
+			}
+
+			story, err := loadCIStory(fs, c.String("from-manifest"))
+			if err != nil {
+				return err
+			}
+
+			matrix := ci.Matrix(story, trunk)
+
+			if structured() {
+				if matrix == nil {
+					matrix = []ci.Entry{}
+				}
+
+				return writeStructured(os.Stdout, matrix)
+			}
+
+			switch format {
+			case "github-actions":
+				return ci.WriteGitHubActions(os.Stdout, matrix)
+			case "gitlab":
+				return ci.WriteGitLab(os.Stdout, matrix)
+			case "json":
+				return ci.WriteJSON(os.Stdout, matrix)
+			default:
+				return ErrUnsupportedFormat(format)
+			}
+		},
+	}
+}
+
+// loadCIStory loads the current story, or the archived manifest of a story when one is named
+func loadCIStory(fs afero.Fs, name string) (*manifest.Story, error) {
+	if name != "" {
+		return manifest.LoadStoryFromBranchName(fs, name)
+	}
+
+	if !isStory {
+		return nil, ErrNotWorkingOnAStory
+	}
+
+	return manifest.LoadStory(fs)
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"sort"
+
+	"github.com/LGUG2Z/story/ci"
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func ciCheckoutCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "checkout",
+		Usage: "Clones the projects of the current story and the dependencies of its artifacts at their pinned hashes",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "from-manifest", Usage: "use the archived manifest of a story instead of the current story"},
+			cli.StringFlag{Name: "token", EnvVar: "STORY_GIT_TOKEN,GITHUB_TOKEN", Usage: "clone over HTTPS, authenticating with a token"},
+			cli.IntFlag{Name: "depth", Value: 1, Usage: "number of commits to fetch for each project"},
+			cli.IntFlag{Name: "parallel", Usage: "maximum number of projects to clone at once (default: number of CPUs)"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := loadCIStory(fs, c.String("from-manifest"))
+			if err != nil {
+				return err
+			}
+
+			// Story projects and artifacts are cloned first, followed by a wave for each level
+			// of dependencies of the artifacts which is discovered from their manifests
+			wave := make(map[string]bool)
+			for project := range story.Projects {
+				wave[project] = true
+			}
+
+			artifacts := make(map[string]bool)
+			for project, artifact := range story.Artifacts {
+				if artifact {
+					wave[project] = true
+					artifacts[project] = true
+				}
+			}
+
+			var failed []string
+			for len(wave) > 0 {
+				var targets []ci.Target
+				for _, project := range sortedProjects(wave) {
+					cloned, err := afero.DirExists(fs, project)
+					if err != nil {
+						return err
+					}
+
+					if cloned {
+						continue
+					}
+
+					targets = append(targets, ciTarget(story, project, c.String("token")))
+				}
+
+				for _, result := range ci.Checkout(targets, c.Int("depth"), c.Int("parallel")) {
+					if result.Err != nil {
+						color.Green(result.Project)
+						color.Red(result.Err.Error())
+						failed = append(failed, result.Project)
+						continue
+					}
+
+					printGitOutput(result.Output, result.Project)
+				}
+
+				next := make(map[string]bool)
+				for project := range wave {
+					if !artifacts[project] {
+						continue
+					}
+
+					dependencies, err := projectDependencies(fs, story, project)
+					if err != nil {
+						return err
+					}
+
+					for _, dependency := range dependencies {
+						if !artifacts[dependency] {
+							artifacts[dependency] = true
+							next[dependency] = true
+						}
+					}
+				}
+
+				wave = next
+			}
+
+			return failedInProjects("clone", failed)
+		},
+	}
+}
+
+// ciTarget checks a project out at its pinned hash, on the story branch if it is in the
+// story without one, and on trunk otherwise
+func ciTarget(story *manifest.Story, project, token string) ci.Target {
+	target := ci.Target{Project: project, Repository: ci.HTTPSURL(story.AllProjects[project], token), Token: token}
+
+	if hash, ok := story.Hashes[project]; ok {
+		target.Hash = hash
+	} else if _, ok := story.Projects[project]; ok {
+		target.Branch = story.Name
+	} else {
+		target.Branch = trunk
+	}
+
+	return target
+}
+
+// projectDependencies returns the metarepo projects that a cloned project declares dependencies on,
+// only in the given sections of its manifest when any are given
+func projectDependencies(fs afero.Fs, story *manifest.Story, project string, sections ...string) ([]string, error) {
+	cloned, err := afero.DirExists(fs, project)
+	if err != nil || !cloned {
+		return nil, err
+	}
+
+	// Projects without a manifest have no dependencies
+	a, err := ecosystem.Detect(fs, project)
+	if err != nil {
+		return nil, nil
+	}
+
+	if err := a.Load(fs, project); err != nil {
+		return nil, err
+	}
+
+	inSections := make(map[string]bool)
+	for _, section := range sections {
+		inSections[section] = true
+	}
+
+	var dependencies []string
+	for _, d := range a.Dependencies() {
+		if len(sections) > 0 && !inSections[d.Section] {
+			continue
+		}
+
+		dependency := d.Name
+		if scoped, ok := graph.ProjectName(story.Orgranisation, d.Name); ok {
+			dependency = scoped
+		}
+
+		if _, ok := story.AllProjects[dependency]; ok && dependency != project {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	return dependencies, nil
+}
+
+func sortedProjects(projects map[string]bool) []string {
+	var sorted []string
+	for project := range projects {
+		sorted = append(sorted, project)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
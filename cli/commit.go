@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
 	"github.com/spf13/afero"
@@ -34,10 +35,31 @@ func CommitCmd(fs afero.Fs) cli.Command {
 
 			// Commit in all the projects
 			messages := []string{fmt.Sprintf("[story commit] %s", c.String("message"))}
+			refresh := false
 			for project := range story.Projects {
+				// Dependencies may have changed if a dependency manifest is being committed
+				staged, err := git.StagedFiles(project)
+				if err != nil {
+					if err := keepGoingOn(project, "commit", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				for _, file := range staged {
+					if ecosystem.IsManifest(file) {
+						refresh = true
+					}
+				}
+
 				output, err := git.Commit(git.CommitOpts{Project: project, Messages: messages})
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "commit", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
@@ -50,6 +72,27 @@ func CommitCmd(fs afero.Fs) cli.Command {
 			}
 
 			story.Hashes = hashes
+
+			// Recalculate the blast radius and artifacts if dependencies may have changed
+			if refresh {
+				gained, lost, err := refreshBlastRadius(fs, story, story.Depth)
+				if err != nil {
+					// The new hashes are still written for the projects which were committed
+					if err := story.Write(fs); err != nil {
+						return err
+					}
+
+					return err
+				}
+
+				// The results of each project are the structured output of commit
+				if !structured() {
+					if err := printArtifactChanges(gained, lost); err != nil {
+						return err
+					}
+				}
+			}
+
 			if err := story.Write(fs); err != nil {
 				return err
 			}
@@ -20,22 +20,26 @@ func CreateCmd(fs afero.Fs) cli.Command {
 				return ErrCommandRequiresAnArgument
 			}
 
-			name := c.Args().First()
+			_, err := createStory(fs, c.Args().First())
+			return err
+		},
+	}
+}
 
-			meta, err := manifest.LoadMetaOnTrunk(fs)
-			if err != nil {
-				return err
-			}
+// createStory creates a story branch on the metarepo and writes the story .meta file
+func createStory(fs afero.Fs, name string) (*manifest.Story, error) {
+	meta, err := manifest.LoadMetaOnTrunk(fs)
+	if err != nil {
+		return nil, err
+	}
 
-			story := manifest.NewStory(name, meta)
-			output, err := git.CheckoutBranch(git.CheckoutBranchOpts{Branch: story.Name, Create: true})
-			if err != nil {
-				return err
-			}
+	story := manifest.NewStory(name, meta)
+	output, err := git.CheckoutBranch(git.CheckoutBranchOpts{Branch: story.Name, Create: true})
+	if err != nil {
+		return nil, err
+	}
 
-			printGitOutput(output, metarepo)
+	printGitOutput(output, metarepo)
 
-			return story.Write(fs)
-		},
-	}
+	return story, story.Write(fs)
 }
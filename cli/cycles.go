@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func CyclesCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "cycles",
+		Usage: "Shows circular dependencies between projects in the metarepo",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "story", Usage: "Only include projects in the current story"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("story") && !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			opts := graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile}
+			if c.Bool("story") {
+				story, err := manifest.LoadStory(fs)
+				if err != nil {
+					return err
+				}
+
+				// Only the manifests of the story projects are read
+				for project := range story.Projects {
+					opts.Projects = append(opts.Projects, project)
+				}
+
+				sort.Strings(opts.Projects)
+			}
+
+			g, err := graph.Build(fs, opts)
+			if err != nil {
+				return err
+			}
+
+			cycles := g.Cycles()
+			if len(cycles) == 0 && !structured() {
+				fmt.Println("no circular dependencies found")
+				return nil
+			}
+
+			if err := printCycles(g, cycles); err != nil {
+				return err
+			}
+
+			if len(cycles) > 0 {
+				return ErrCyclesFound(len(cycles))
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/LGUG2Z/story/deploy"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+func DeployManifestCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "deploy-manifest",
+		Usage: "Shows the image tags of the artifacts of the current story or trunk as a deploy descriptor",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "type", Value: "helm", Usage: "Descriptor type (helm, kustomize)"},
+			cli.StringFlag{Name: "format", Value: "yaml", Usage: "Output format (yaml, json)"},
+			cli.StringFlag{Name: "image-registry", EnvVar: "STORY_IMAGE_REGISTRY", Usage: "registry prefixed to the image name of every artifact"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			format, err := commandFormat(c)
+			if err != nil {
+				return err
+			}
+
+			commits, err := artifactCommits(fs)
+			if err != nil {
+				return err
+			}
+
+			var images []deploy.Image
+			for project, projectCommits := range commits {
+				repository := project
+				if c.String("image-registry") != "" {
+					repository = fmt.Sprintf("%s/%s", c.String("image-registry"), project)
+				}
+
+				images = append(images, deploy.Image{Project: project, Repository: repository, Tag: deploy.ImageTag(project, projectCommits)})
+			}
+
+			sort.Slice(images, func(i, j int) bool {
+				return images[i].Project < images[j].Project
+			})
+
+			var descriptor interface{}
+			switch c.String("type") {
+			case "helm":
+				descriptor = deploy.Helm(images)
+			case "kustomize":
+				descriptor = deploy.Kustomize(images)
+			default:
+				return ErrUnsupportedDescriptorType(c.String("type"))
+			}
+
+			if structured() {
+				return writeStructured(os.Stdout, descriptor)
+			}
+
+			var bytes []byte
+			switch format {
+			case "yaml":
+				bytes, err = yaml.Marshal(descriptor)
+			case "json":
+				bytes, err = json.MarshalIndent(descriptor, "", "  ")
+				bytes = append(bytes, '\n')
+			default:
+				return ErrUnsupportedFormat(format)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			_, err = os.Stdout.Write(bytes)
+			return err
+		},
+	}
+}
+
+// artifactCommits returns the commits each artifact image is built from. On a story these
+// are the hash of the artifact and the hashes of its story dependencies, and on trunk they
+// are the current commit of every artifact in the trunk .meta file. Artifacts which need a
+// current commit but have not been cloned are skipped.
+func artifactCommits(fs afero.Fs) (map[string]map[string]string, error) {
+	commits := make(map[string]map[string]string)
+
+	if !isStory {
+		meta, err := manifest.LoadMetaOnTrunk(fs)
+		if err != nil {
+			return nil, err
+		}
+
+		// Artifacts are only set on stories, so every artifact of trunk is deployed
+		for artifact := range meta.Artifacts {
+			cloned, err := afero.DirExists(fs, artifact)
+			if err != nil {
+				return nil, err
+			}
+
+			if !cloned {
+				color.Yellow("skipping %s as it has not been cloned", artifact)
+				continue
+			}
+
+			hash, err := git.HeadCommit(artifact)
+			if err != nil {
+				return nil, err
+			}
+
+			commits[artifact] = map[string]string{artifact: hash}
+		}
+
+		return commits, nil
+	}
+
+	story, err := manifest.LoadStory(fs)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+	if err != nil {
+		return nil, err
+	}
+
+	for artifact, isArtifact := range story.Artifacts {
+		if !isArtifact {
+			continue
+		}
+
+		hash, ok := story.Hashes[artifact]
+		if !ok {
+			cloned, err := afero.DirExists(fs, artifact)
+			if err != nil {
+				return nil, err
+			}
+
+			if !cloned {
+				color.Yellow("skipping %s as it has not been cloned", artifact)
+				continue
+			}
+
+			if hash, err = git.HeadCommit(artifact); err != nil {
+				return nil, err
+			}
+		}
+
+		commits[artifact] = map[string]string{artifact: hash}
+		for _, dependency := range g.TransitiveDependencies(artifact, 0) {
+			if hash, ok := story.Hashes[dependency]; ok {
+				commits[artifact][dependency] = hash
+			}
+		}
+	}
+
+	return commits, nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/drift"
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func DriftCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "drift",
+		Usage: "Shows external dependencies used with different versions across the metarepo",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "format", Value: "text", Usage: "Output format (text, json)"},
+			cli.IntFlag{Name: "fail-on", Usage: "return an error if a dependency is used with at least this many different versions"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			format, err := commandFormat(c)
+			if err != nil {
+				return err
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			var projects []string
+			internal := make(map[string]bool)
+			for _, project := range g.Projects {
+				a, err := ecosystem.Detect(fs, project)
+				if err != nil {
+					return err
+				}
+
+				if a.Ecosystem() == "node" {
+					projects = append(projects, project)
+					internal[g.Packages[project]] = true
+				}
+			}
+
+			drifted, err := drift.Find(fs, projects, internal)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case structured():
+				if drifted == nil {
+					drifted = []drift.Dependency{}
+				}
+
+				err = writeStructured(os.Stdout, drifted)
+			case format == "text":
+				err = drift.WriteText(os.Stdout, drifted)
+			case format == "json":
+				err = drift.WriteJSON(os.Stdout, drifted)
+			default:
+				return ErrUnsupportedFormat(format)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if threshold := c.Int("fail-on"); threshold > 0 {
+				for _, d := range drifted {
+					if len(d.Usages) >= threshold {
+						return ErrDriftFound(d.Name, len(d.Usages))
+					}
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"golang.org/x/oauth2"
+)
+
+// dryRunFs is the filesystem given to every command. When dry running, writes are kept
+// in memory on top of the real filesystem so that commands still see their own changes
+type dryRunFs struct {
+	afero.Fs
+	base    afero.Fs
+	mutex   sync.Mutex
+	written map[string]bool
+}
+
+func newDryRunFs(base afero.Fs) *dryRunFs {
+	return &dryRunFs{Fs: base, base: base}
+}
+
+func (fs *dryRunFs) enable(on bool) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.Fs = fs.base
+	fs.written = nil
+
+	if on {
+		fs.Fs = afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(fs.base), afero.NewMemMapFs())
+		fs.written = make(map[string]bool)
+	}
+}
+
+func (fs *dryRunFs) Create(name string) (afero.File, error) {
+	fs.record(name)
+	return fs.Fs.Create(name)
+}
+
+func (fs *dryRunFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		fs.record(name)
+	}
+
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *dryRunFs) record(name string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.written != nil {
+		fs.written[filepath.Clean(name)] = true
+	}
+}
+
+// changes returns the files written while dry running, excluding the caches kept in .git
+func (fs *dryRunFs) changes() []string {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	var names []string
+	for name := range fs.written {
+		if !strings.HasPrefix(name, ".git/") && !strings.Contains(name, "/.git/") {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// diff returns a unified diff of a file written while dry running
+func (fs *dryRunFs) diff(name string) (string, error) {
+	before, err := afero.ReadFile(fs.base, name)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	after, err := afero.ReadFile(fs.Fs, name)
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+		if bytes.Equal(before, after) {
+			return "", nil
+		}
+
+		return fmt.Sprintf("Binary file %s would change\n", name), nil
+	}
+
+	return dryrun.Unified(name, before, after), nil
+}
+
+// dryRunContext returns a context whose HTTP client records requests which would change
+// anything instead of sending them when dry running
+func dryRunContext(ctx context.Context) context.Context {
+	if !dryrun.Enabled() {
+		return ctx
+	}
+
+	return context.WithValue(ctx, oauth2.HTTPClient, dryRunHTTPClient())
+}
+
+func dryRunHTTPClient() *http.Client {
+	return &http.Client{Transport: &dryrun.Transport{Base: http.DefaultTransport}}
+}
+
+// printDryRun prints the operations recorded for each project and a diff of every file
+// which would have been written
+func printDryRun(fs *dryRunFs) error {
+	if !dryrun.Enabled() {
+		return nil
+	}
+
+	// Structured output is kept parseable by printing the plan alongside the messages
+	var w io.Writer = os.Stdout
+	if structured() {
+		w = os.Stderr
+	}
+
+	fmt.Fprintln(w, "dry run, no changes were made")
+
+	var current string
+	for i, operation := range dryrun.Operations() {
+		project := operation.Project
+		if project == "" {
+			project = metarepo
+		}
+
+		if i == 0 || project != current {
+			fmt.Fprintln(w, color.GreenString(project))
+			current = project
+		}
+
+		fmt.Fprintf(w, "  %s\n", operation.Description)
+	}
+
+	for _, name := range fs.changes() {
+		diff, err := fs.diff(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(w, diff)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/env"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func EnvCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "env",
+		Usage: "Shows the state of the current story or trunk as environment variables",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "format", Value: "sh", Usage: "Output format (sh, dotenv, json)"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			format, err := commandFormat(c)
+			if err != nil {
+				return err
+			}
+
+			var variables []env.Variable
+			if isStory {
+				story, err := manifest.LoadStory(fs)
+				if err != nil {
+					return err
+				}
+
+				if variables, err = env.FromStory(story, trunk); err != nil {
+					return err
+				}
+			} else {
+				meta, err := manifest.LoadMetaOnTrunk(fs)
+				if err != nil {
+					return err
+				}
+
+				variables = env.FromMeta(meta, trunk)
+			}
+
+			if structured() {
+				return writeStructured(os.Stdout, env.Object(variables))
+			}
+
+			switch format {
+			case "sh":
+				return env.WriteShell(os.Stdout, variables)
+			case "dotenv":
+				return env.WriteDotenv(os.Stdout, variables)
+			case "json":
+				return env.WriteJSON(os.Stdout, variables)
+			default:
+				return ErrUnsupportedFormat(format)
+			}
+		},
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 )
 
 var ErrAlreadyWorkingOnAStory = fmt.Errorf("already working on a story")
@@ -18,3 +19,71 @@ func ErrCouldNotFindOpenPullRequest(story string) error {
 func ErrCouldNotFindClosedPullRequest(story string) error {
 	return fmt.Errorf("could not find a closed pull request for %s", story)
 }
+
+func ErrNotInBlastRadius(project string) error {
+	return fmt.Errorf("%s is not in the blast radius of the current story", project)
+}
+
+func ErrUnsupportedFormat(format string) error {
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+func ErrNotAnArtifact(project string) error {
+	return fmt.Errorf("%s is not an artifact in the metarepo", project)
+}
+
+func ErrMetarepoNotFound(dir string) error {
+	return fmt.Errorf("could not find a metarepo with a .meta file and a .git directory in %s or any parent directory, use --metarepo to set it", dir)
+}
+
+var ErrNothingToUndo = fmt.Errorf("there are no commands in the journal to undo")
+
+func ErrJournalEntryOutdated(command string, projects []string) error {
+	return fmt.Errorf("cannot undo '%s' as %s changed since, use --force to undo it anyway", command, strings.Join(projects, ", "))
+}
+
+func ErrDryRunNotSupported(command string) error {
+	return fmt.Errorf("%s runs commands or changes files outside of story and cannot be used with --dry-run", command)
+}
+
+func ErrUnsupportedDescriptorType(descriptor string) error {
+	return fmt.Errorf("unsupported descriptor type: %s", descriptor)
+}
+
+func ErrStoryProjectsFormACycle(cycle []string) error {
+	return fmt.Errorf("story projects depend on each other and cannot be pinned consistently: %s", strings.Join(cycle, ", "))
+}
+
+func ErrCyclesFound(count int) error {
+	return fmt.Errorf("found %d circular dependencies", count)
+}
+
+func ErrNoCommitHash(project string) error {
+	return fmt.Errorf("there is no commit hash for %s in the current story", project)
+}
+
+var ErrReleaseFromTrunk = fmt.Errorf("releases can only be made from the trunk branch")
+
+func ErrProjectNotInStory(project, story string) error {
+	return fmt.Errorf("%s is not a project in %s", project, story)
+}
+
+var ErrPinModesAreExclusive = fmt.Errorf("only one of --registry and --tags can be used to pin a story")
+
+func ErrDriftFound(dependency string, specifiers int) error {
+	return fmt.Errorf("%s is used with %d different specifiers", dependency, specifiers)
+}
+
+var ErrCommandRequiresTwoArguments = fmt.Errorf("this command requires two arguments")
+
+func ErrCommandFailedInProjects(projects []string) error {
+	return fmt.Errorf("the command failed in %s", strings.Join(projects, ", "))
+}
+
+func ErrDependencyNotCloned(project, dependency string) error {
+	return fmt.Errorf("%s depends on %s, which has not been cloned", project, dependency)
+}
+
+func ErrFormatConflictsWithOutput(format, output string) error {
+	return fmt.Errorf("--format %s cannot be used with --output %s, which replaces the format of the command", format, output)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"sort"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/runner"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func ExecCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:      "exec",
+		Usage:     "Runs a command in every project of the current story in parallel",
+		ArgsUsage: "-- <command> [arguments...]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "blast-radius", Usage: "also run the command in every project in the blast radius"},
+			cli.BoolFlag{Name: "all", Usage: "run the command in every cloned project in the metarepo"},
+			cli.BoolFlag{Name: "stage", Usage: "stage the changes made by the command for the next story commit"},
+			cli.IntFlag{Name: "parallel", Usage: "maximum number of projects to run the command in at once (default: number of CPUs)"},
+		},
+		Action: func(c *cli.Context) error {
+			if dryrun.Enabled() {
+				return ErrDryRunNotSupported("exec")
+			}
+
+			if !isStory && !c.Bool("all") {
+				return ErrNotWorkingOnAStory
+			}
+
+			if !c.Args().Present() {
+				return ErrCommandRequiresAnArgument
+			}
+
+			var projects []string
+			if c.Bool("all") {
+				var allProjects map[string]string
+				if isStory {
+					story, err := manifest.LoadStory(fs)
+					if err != nil {
+						return err
+					}
+
+					allProjects = story.AllProjects
+				} else {
+					meta, err := manifest.LoadMetaOnTrunk(fs)
+					if err != nil {
+						return err
+					}
+
+					allProjects = meta.Projects
+				}
+
+				for project := range allProjects {
+					projects = append(projects, project)
+				}
+
+				sort.Strings(projects)
+			} else {
+				story, err := manifest.LoadStory(fs)
+				if err != nil {
+					return err
+				}
+
+				projects = storyProjects(story, c.Bool("blast-radius"))
+			}
+
+			var tasks []runner.Task
+			for _, project := range projects {
+				cloned, err := afero.DirExists(fs, project)
+				if err != nil {
+					return err
+				}
+
+				if !cloned {
+					color.Yellow("skipping %s as it has not been cloned", project)
+					continue
+				}
+
+				tasks = append(tasks, runner.Task{Project: project, Command: c.Args()})
+			}
+
+			var failed []string
+			for _, result := range runner.Run(tasks, c.Int("parallel")) {
+				printRunnerResult(result)
+
+				if result.Failed() {
+					failed = append(failed, result.Project)
+					continue
+				}
+
+				if c.Bool("stage") {
+					if _, err := git.Add(git.AddOpts{Project: result.Project, Files: []string{"--all"}}); err != nil {
+						return err
+					}
+				}
+			}
+
+			return failedInProjects("exec", failed)
+		},
+	}
+}
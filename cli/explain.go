@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func ExplainCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "explain",
+		Usage: "Shows why a project is in the blast radius of the current story",
+		Action: func(c *cli.Context) error {
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			args := projectArgs(c)
+			if len(args) == 0 {
+				return ErrCommandRequiresAnArgument
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			// Projects in the story can also be in the blast radius of other story projects
+			target := args[0]
+			_, added := story.Projects[target]
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			var projects []string
+			for project := range story.Projects {
+				projects = append(projects, project)
+			}
+
+			sort.Strings(projects)
+
+			explanation := Explanation{Project: target, Added: added, Paths: []Path{}}
+			for _, project := range projects {
+				// Follow the dependencies of the target back to each project in the story
+				for _, edges := range g.Paths(target, project) {
+					path := Path{Projects: []string{target}, Edges: edges}
+					for _, edge := range edges {
+						path.Projects = append(path.Projects, edge.To)
+					}
+
+					explanation.Paths = append(explanation.Paths, path)
+				}
+			}
+
+			if len(explanation.Paths) == 0 && !added {
+				return ErrNotInBlastRadius(target)
+			}
+
+			if structured() {
+				return writeStructured(os.Stdout, explanation)
+			}
+
+			if added {
+				fmt.Printf("%s has been added to the story\n", target)
+			}
+
+			for _, path := range explanation.Paths {
+				color.Green(strings.Join(path.Projects, " -> "))
+				for _, edge := range path.Edges {
+					fmt.Printf("  %s -> %s (%s: %s)\n", edge.From, edge.To, edge.Section, edge.Specifier)
+				}
+			}
+
+			return nil
+		},
+	}
+}
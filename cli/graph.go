@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func GraphCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "graph",
+		Usage: "Exports the dependency graph of the metarepo",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "format", Value: "dot", Usage: "Output format (dot, mermaid, json)"},
+			cli.BoolFlag{Name: "story", Usage: "Only include projects in the current story and its blast radius"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("story") && !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			format, err := commandFormat(c)
+			if err != nil {
+				return err
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			h := graph.Highlights{
+				Story:       make(map[string]bool),
+				BlastRadius: make(map[string]bool),
+				Artifacts:   make(map[string]bool),
+			}
+
+			if isStory {
+				story, err := manifest.LoadStory(fs)
+				if err != nil {
+					return err
+				}
+
+				for project := range story.Projects {
+					h.Story[project] = true
+				}
+
+				for _, br := range story.BlastRadius {
+					for _, p := range br {
+						h.BlastRadius[p] = true
+					}
+				}
+
+				for project, isArtifact := range story.Artifacts {
+					h.Artifacts[project] = isArtifact
+				}
+			}
+
+			if c.Bool("story") {
+				projects := make(map[string]bool)
+				for project := range h.Story {
+					projects[project] = true
+				}
+
+				for project := range h.BlastRadius {
+					projects[project] = true
+				}
+
+				g = g.Subgraph(projects)
+			}
+
+			if structured() {
+				return writeStructured(os.Stdout, g.Document(h))
+			}
+
+			switch format {
+			case "dot":
+				return g.WriteDOT(os.Stdout, h)
+			case "mermaid":
+				return g.WriteMermaid(os.Stdout, h)
+			case "json":
+				return g.WriteJSON(os.Stdout, h)
+			default:
+				return ErrUnsupportedFormat(format)
+			}
+		},
+	}
+}
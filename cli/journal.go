@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/journal"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+// journaled is the set of commands which can change the metarepo or its projects, and
+// which record a journal entry that can be undone
+var journaled = map[string]bool{
+	"create":     true,
+	"load":       true,
+	"reset":      true,
+	"add":        true,
+	"remove":     true,
+	"bump-dep":   true,
+	"exec":       true,
+	"commit":     true,
+	"push":       true,
+	"unpin":      true,
+	"pin":        true,
+	"prepare":    true,
+	"update":     true,
+	"merge":      true,
+	"release":    true,
+	"prune-tags": true,
+	"pr":         true,
+}
+
+// remote is the set of journaled commands which also change remotes, which undo can't revert
+var remote = map[string]bool{
+	"push":    true,
+	"release": true,
+	"pr":      true,
+}
+
+var recorder *journal.Recorder
+
+// beginJournal starts recording a journal entry for a command which can change anything
+func beginJournal(fs afero.Fs, args []string) error {
+	recorder = nil
+	if len(args) == 0 || !journaled[args[0]] || dryrun.Enabled() {
+		return nil
+	}
+
+	var err error
+	recorder, err = journal.Begin(fs, args)
+	return err
+}
+
+// endJournal writes the journal entry of the command if it changed anything, including
+// when it failed part of the way through
+func endJournal() error {
+	if recorder == nil {
+		return nil
+	}
+
+	defer func() { recorder = nil }()
+
+	_, err := recorder.End()
+	return err
+}
+
+// JournalEntry is an entry in the output of the journal command
+type JournalEntry struct {
+	ID       string    `json:"id" yaml:"id"`
+	Time     time.Time `json:"time" yaml:"time"`
+	Command  string    `json:"command" yaml:"command"`
+	Affected []string  `json:"affected" yaml:"affected"`
+}
+
+func JournalCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "journal",
+		Usage: "Shows the history of commands which can be undone, newest first",
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			entries, err := journal.List(fs)
+			if err != nil {
+				return err
+			}
+
+			rows := []JournalEntry{}
+			for _, entry := range entries {
+				rows = append(rows, JournalEntry{
+					ID:       entry.ID,
+					Time:     entry.Time,
+					Command:  strings.Join(entry.Command, " "),
+					Affected: journalNames(entry.Affected()),
+				})
+			}
+
+			if structured() {
+				return writeStructured(os.Stdout, rows)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tCOMMAND\tAFFECTED")
+			for _, row := range rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", row.Time.Local().Format("2006-01-02 15:04:05"), row.Command, strings.Join(row.Affected, ", "))
+			}
+
+			return w.Flush()
+		},
+	}
+}
+
+func UndoCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "undo",
+		Usage: "Reverts the local changes of the latest command in the journal; pushes, releases and pull requests are not undone",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "force", Usage: "revert even if repositories have changed since the command"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			entries, err := journal.List(fs)
+			if err != nil {
+				return err
+			}
+
+			if len(entries) == 0 {
+				return ErrNothingToUndo
+			}
+
+			entry := entries[0]
+			if remote[entry.Command[0]] {
+				color.Yellow("warning: only the local changes of '%s' will be undone, its changes to remotes will remain", strings.Join(entry.Command, " "))
+			}
+
+			if !c.Bool("force") {
+				outdated, err := journal.Outdated(fs, entry)
+				if err != nil {
+					return err
+				}
+
+				if len(outdated) > 0 {
+					return ErrJournalEntryOutdated(strings.Join(entry.Command, " "), journalNames(outdated))
+				}
+			}
+
+			// Changes to the files would stop repositories from being checked out, and the
+			// files are restored once they have been
+			if err := journal.DiscardFiles(fs, entry); err != nil {
+				return err
+			}
+
+			var projects []string
+			for project := range entry.Projects {
+				projects = append(projects, project)
+			}
+
+			sort.Strings(projects)
+
+			for _, project := range projects {
+				output, err := journal.Revert(entry, project)
+				if err != nil {
+					if err := keepGoingOn(journalName(project), "undo", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				printGitOutput(output, journalName(project))
+			}
+
+			if err := journal.RestoreFiles(fs, entry); err != nil {
+				return err
+			}
+
+			var cloned []string
+			for project := range entry.Cloned {
+				cloned = append(cloned, project)
+			}
+
+			sort.Strings(cloned)
+
+			for _, project := range cloned {
+				if err := journal.RemoveCloned(fs, entry, project); err != nil {
+					if err := keepGoingOn(project, "undo", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				printGitOutput("removed clone", project)
+			}
+
+			// The entry is kept when nothing was undone, or not everything was
+			if dryrun.Enabled() || len(projectErrors) > 0 {
+				return nil
+			}
+
+			printGitOutput(fmt.Sprintf("undid %s", strings.Join(entry.Command, " ")), metarepo)
+
+			return journal.Remove(fs, entry)
+		},
+	}
+}
+
+// journalNames returns the names of repositories and files in the journal, with the
+// metarepo shown by name
+func journalNames(affected []string) []string {
+	var names []string
+	for _, name := range affected {
+		names = append(names, journalName(name))
+	}
+
+	return names
+}
+
+func journalName(project string) string {
+	if project == journal.Metarepo {
+		return metarepo
+	}
+
+	return project
+}
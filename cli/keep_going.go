@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// ExitCodePartialFailure is the exit code when --keep-going is set and some, but not
+// necessarily all, projects failed
+const ExitCodePartialFailure = 3
+
+var keepGoing bool
+var projectErrors []*ProjectError
+
+// ProjectError is an error from an operation in a project
+type ProjectError struct {
+	Project   string
+	Operation string
+	Err       error
+}
+
+func (e *ProjectError) Error() string {
+	return fmt.Sprintf("%s failed in %s: %s", e.Operation, e.Project, e.Err)
+}
+
+// ErrPartialFailure is returned when --keep-going is set and operations failed in some projects
+type ErrPartialFailure struct {
+	Errors []*ProjectError
+}
+
+func (e *ErrPartialFailure) Error() string {
+	var projects []string
+	seen := make(map[string]bool)
+	for _, pe := range e.Errors {
+		if !seen[pe.Project] {
+			seen[pe.Project] = true
+			projects = append(projects, pe.Project)
+		}
+	}
+
+	sort.Strings(projects)
+
+	return fmt.Sprintf("%d operations failed in %s", len(e.Errors), strings.Join(projects, ", "))
+}
+
+// ExitCode is the exit code of the process for an error returned by the app, including
+// errors from the command and the After hook which are returned together
+func ExitCode(err error) int {
+	switch e := err.(type) {
+	case *ErrPartialFailure:
+		return ExitCodePartialFailure
+	case cli.MultiError:
+		for _, err := range e.Errors {
+			if ExitCode(err) == ExitCodePartialFailure {
+				return ExitCodePartialFailure
+			}
+		}
+	}
+
+	return 1
+}
+
+// keepGoingOn returns the error from an operation in a project, unless --keep-going is
+// set, in which case the error is recorded and nil is returned so that the caller can
+// move on to the next project
+func keepGoingOn(project, operation string, err error) error {
+	if !keepGoing {
+		return err
+	}
+
+	projectErrors = append(projectErrors, &ProjectError{Project: project, Operation: operation, Err: err})
+	return nil
+}
+
+// failedInProjects is returned by commands which run in every project regardless of
+// --keep-going, recording each failure when it is set
+func failedInProjects(operation string, failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if !keepGoing {
+		return ErrCommandFailedInProjects(failed)
+	}
+
+	for _, project := range failed {
+		projectErrors = append(projectErrors, &ProjectError{Project: project, Operation: operation, Err: fmt.Errorf("the command failed")})
+	}
+
+	return nil
+}
+
+// printProjectErrors prints a summary table of the errors recorded with --keep-going, and
+// returns an error with a distinct exit code when there were any
+func printProjectErrors() error {
+	if len(projectErrors) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tOPERATION\tERROR")
+	for _, pe := range projectErrors {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pe.Project, pe.Operation, strings.Replace(strings.TrimSpace(pe.Err.Error()), "\n", " ", -1))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return &ErrPartialFailure{Errors: projectErrors}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/link"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func LinkCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "link",
+		Usage: "Links the node_modules of story projects to the checkouts of their story dependencies",
+		Action: cli.ActionFunc(func(c *cli.Context) error {
+			if dryrun.Enabled() {
+				return ErrDryRunNotSupported("link")
+			}
+
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			links, err := storyLinks(fs, story)
+			if err != nil {
+				return err
+			}
+
+			for _, l := range links {
+				created, err := link.Create(l)
+				if err != nil {
+					if err := keepGoingOn(l.Project, "link", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				if created {
+					printGitOutput(fmt.Sprintf("linked node_modules/%s to %s", l.Package, l.Target), l.Project)
+				}
+			}
+
+			return nil
+		}),
+	}
+}
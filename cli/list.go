@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"fmt"
-
 	"github.com/LGUG2Z/story/manifest"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
@@ -26,11 +24,12 @@ func ListCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
+			var projects []string
 			for project := range story.Projects {
-				fmt.Println(project)
+				projects = append(projects, project)
 			}
 
-			return nil
+			return printRecords(storyRecords(story, projects))
 		},
 	}
 }
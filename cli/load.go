@@ -35,12 +35,20 @@ func LoadCmd(fs afero.Fs) cli.Command {
 
 			for project := range story.Projects {
 				if err := ensureProjectIsCloned(fs, story, project); err != nil {
-					return err
+					if err := keepGoingOn(project, "clone", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				output, err := git.CheckoutBranch(git.CheckoutBranchOpts{Branch: name, Project: project})
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "checkout", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
@@ -6,9 +6,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/LGUG2Z/story/dryrun"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/fatih/color"
 	"github.com/google/go-github/github"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
@@ -54,7 +54,6 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				story.Projects[metarepo] = ""
 
 				for project := range story.Projects {
-					color.Green(project)
 					// Get the open pull request
 					openPullRequest, err := getOpenPullRequest(client, ctx, story, project)
 					if err != nil {
@@ -67,22 +66,25 @@ func MergeCmd(fs afero.Fs) cli.Command {
 								switch err.Error() {
 								// If there is no closed pull request, a pull request was never opened
 								case ErrCouldNotFindClosedPullRequest(story.Name).Error():
-									fmt.Printf("could not find an open or closed pull request for %s\n", story.Name)
+									printGitOutput(fmt.Sprintf("could not find an open or closed pull request for %s", story.Name), project)
 									continue
 								// Something else went wrong here with the call to list closed pull requests
 								default:
-									fmt.Println(err)
+									printGitOutput(err.Error(), project)
 									continue
 								}
 							}
 
 							// Report that the pull request has already been closed with a link
-							fmt.Println("pull request has already been closed")
-							fmt.Println(*closedPullRequest.HTMLURL)
+							printGitOutput(fmt.Sprintf("pull request has already been closed\n%s", *closedPullRequest.HTMLURL), project)
 							continue
 						// Something else went wrong here with the call to list open pull requests
 						default:
-							return err
+							if err := keepGoingOn(project, "merge", err); err != nil {
+								return err
+							}
+
+							continue
 						}
 					}
 
@@ -93,28 +95,39 @@ func MergeCmd(fs afero.Fs) cli.Command {
 					})
 
 					if err != nil {
-						return err
+						if err := keepGoingOn(project, "merge", err); err != nil {
+							return err
+						}
+
+						continue
 					}
 
-					switch resp.StatusCode {
-					case http.StatusOK:
-						fmt.Println("pull request successfully merged")
-					case http.StatusMethodNotAllowed:
-						fmt.Println("pull request is not mergeable")
-					case http.StatusConflict:
-						fmt.Println("head branch was modified, review and try the merge again")
+					var status string
+					switch {
+					case dryrun.Enabled():
+						status = "would merge pull request"
+					case resp.StatusCode == http.StatusOK:
+						status = "pull request successfully merged"
+					case resp.StatusCode == http.StatusMethodNotAllowed:
+						status = "pull request is not mergeable"
+					case resp.StatusCode == http.StatusConflict:
+						status = "head branch was modified, review and try the merge again"
 					}
 
-					fmt.Println(*openPullRequest.HTMLURL)
+					printGitOutput(fmt.Sprintf("%s\n%s", status, *openPullRequest.HTMLURL), project)
 					time.Sleep(1 * time.Second)
 				}
 
+				delete(story.Projects, metarepo)
+				if len(story.Tags) > 0 {
+					return pruneStoryTags(fs, story)
+				}
+
 				return nil
 			}
 
 			// Checkout master and merge story in all projects
 			for project := range story.Projects {
-				color.Green(project)
 				checkoutBranchOutput, err := git.CheckoutBranch(git.CheckoutBranchOpts{
 					Branch:  trunk,
 					Project: project,
@@ -122,10 +135,12 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				})
 
 				if err != nil {
-					return err
-				}
+					if err := keepGoingOn(project, "checkout", err); err != nil {
+						return err
+					}
 
-				fmt.Printf("%s\n\n", checkoutBranchOutput)
+					continue
+				}
 
 				mergeOutput, err := git.Merge(git.MergeOpts{
 					SourceBranch:      story.Name,
@@ -135,10 +150,12 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				})
 
 				if err != nil {
-					return err
-				}
+					if err := keepGoingOn(project, "merge", err); err != nil {
+						return err
+					}
 
-				fmt.Printf("%s\n\n", mergeOutput)
+					continue
+				}
 
 				commitOutput, err := git.Commit(
 					git.CommitOpts{
@@ -148,13 +165,16 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				)
 
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "commit", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				fmt.Println(commitOutput)
+				printGitOutput(fmt.Sprintf("%s\n\n%s\n\n%s", checkoutBranchOutput, mergeOutput, commitOutput), project)
 			}
 
-			color.Green(metarepo)
 			checkoutBranchOutput, err := git.CheckoutBranch(git.CheckoutBranchOpts{
 				Branch: trunk,
 				Create: false,
@@ -164,8 +184,6 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
-			fmt.Printf("%s\n\n", checkoutBranchOutput)
-
 			// Merge story into master on the metarepo
 			mergeOutput, err := git.Merge(git.MergeOpts{
 				SourceBranch:      story.Name,
@@ -177,14 +195,17 @@ func MergeCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
-			fmt.Printf("%s\n\n", mergeOutput)
-
 			commitOutput, err := git.Commit(git.CommitOpts{Messages: messages})
 			if err != nil {
 				return err
 			}
 
-			fmt.Println(commitOutput)
+			printGitOutput(fmt.Sprintf("%s\n\n%s\n\n%s", checkoutBranchOutput, mergeOutput, commitOutput), metarepo)
+
+			// Only the tags the story was last pinned to are still needed once it is merged
+			if len(story.Tags) > 0 {
+				return pruneStoryTags(fs, story)
+			}
 
 			return nil
 		}),
@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/runner"
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats for the global --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+var output string
+var results []Result
+
+// colorOutput is where colored progress is written in text mode; in structured modes it
+// is written to stderr instead so that stdout can be parsed
+var colorOutput = color.Output
+
+// Record is a project in the output of read commands. BlastRadius contains the story
+// projects whose changes put the project in the blast radius.
+type Record struct {
+	Project     string   `json:"project" yaml:"project"`
+	URL         string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Hash        string   `json:"hash,omitempty" yaml:"hash,omitempty"`
+	Artifact    bool     `json:"artifact" yaml:"artifact"`
+	BlastRadius []string `json:"blastRadius,omitempty" yaml:"blastRadius,omitempty"`
+}
+
+// Result is the outcome of a write command in a project
+type Result struct {
+	Project  string `json:"project" yaml:"project"`
+	Output   string `json:"output,omitempty" yaml:"output,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty" yaml:"exitCode,omitempty"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+	Skipped  string `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// Cycle is a group of projects which depend on each other, with the edges between them
+type Cycle struct {
+	Projects []string     `json:"projects" yaml:"projects"`
+	Edges    []graph.Edge `json:"edges" yaml:"edges"`
+}
+
+// Path is a chain of dependencies from one project to another
+type Path struct {
+	Projects []string     `json:"projects" yaml:"projects"`
+	Edges    []graph.Edge `json:"edges" yaml:"edges"`
+}
+
+// Explanation is why a project is in the blast radius of the current story
+type Explanation struct {
+	Project string `json:"project" yaml:"project"`
+	Added   bool   `json:"added" yaml:"added"`
+	Paths   []Path `json:"paths" yaml:"paths"`
+}
+
+// ArtifactChanges are the projects which became or stopped being artifacts of the story
+// when its blast radius was recalculated
+type ArtifactChanges struct {
+	Gained []string `json:"gained" yaml:"gained"`
+	Lost   []string `json:"lost" yaml:"lost"`
+}
+
+func structured() bool {
+	return output != OutputText
+}
+
+// setOutput selects the output format and resets the results of any previous command
+func setOutput(format string) error {
+	switch format {
+	case OutputText:
+		color.Output = colorOutput
+	case OutputJSON, OutputYAML:
+		color.Output = os.Stderr
+	default:
+		return ErrUnsupportedFormat(format)
+	}
+
+	output = format
+	results = nil
+
+	return nil
+}
+
+// commandFormat returns the --format of a command. Structured output replaces the formats
+// of individual commands, so the two can't be set together.
+func commandFormat(c *cli.Context) (string, error) {
+	if structured() && c.IsSet("format") {
+		return "", ErrFormatConflictsWithOutput(c.String("format"), output)
+	}
+
+	return c.String("format"), nil
+}
+
+func writeStructured(w io.Writer, v interface{}) error {
+	if output == OutputYAML {
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// storyRecords returns sorted records for projects in a story
+func storyRecords(story *manifest.Story, projects []string) []Record {
+	sources := make(map[string][]string)
+	for source, br := range story.BlastRadius {
+		for _, project := range br {
+			sources[project] = append(sources[project], source)
+		}
+	}
+
+	var records []Record
+	for _, project := range projects {
+		sort.Strings(sources[project])
+
+		url, ok := story.Projects[project]
+		if !ok {
+			url = story.AllProjects[project]
+		}
+
+		records = append(records, Record{
+			Project:     project,
+			URL:         url,
+			Hash:        story.Hashes[project],
+			Artifact:    story.Artifacts[project],
+			BlastRadius: sources[project],
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Project < records[j].Project
+	})
+
+	return records
+}
+
+// cycleRecords returns the cycles of a graph along with the edges between their projects
+func cycleRecords(g *graph.Graph, cycles [][]string) []Cycle {
+	records := []Cycle{}
+	for _, cycle := range cycles {
+		members := make(map[string]bool)
+		for _, project := range cycle {
+			members[project] = true
+		}
+
+		record := Cycle{Projects: cycle, Edges: []graph.Edge{}}
+		for _, project := range cycle {
+			for _, edge := range g.Dependencies(project) {
+				if members[edge.To] {
+					record.Edges = append(record.Edges, edge)
+				}
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// printCycles prints each cycle and the edges between its projects
+func printCycles(g *graph.Graph, cycles [][]string) error {
+	records := cycleRecords(g, cycles)
+	if structured() {
+		return writeStructured(os.Stdout, records)
+	}
+
+	for _, cycle := range records {
+		color.Red(strings.Join(cycle.Projects, ", "))
+		for _, edge := range cycle.Edges {
+			fmt.Printf("  %s -> %s (%s: %s)\n", edge.From, edge.To, edge.Section, edge.Specifier)
+		}
+	}
+
+	return nil
+}
+
+// printArtifactChanges prints the projects which became or stopped being artifacts
+func printArtifactChanges(gained, lost []string) error {
+	if structured() {
+		changes := ArtifactChanges{Gained: gained, Lost: lost}
+		if changes.Gained == nil {
+			changes.Gained = []string{}
+		}
+
+		if changes.Lost == nil {
+			changes.Lost = []string{}
+		}
+
+		return writeStructured(os.Stdout, changes)
+	}
+
+	if len(gained) == 0 && len(lost) == 0 {
+		fmt.Println("no changes to artifacts")
+		return nil
+	}
+
+	for _, project := range gained {
+		color.Green("+ %s", project)
+	}
+
+	for _, project := range lost {
+		color.Red("- %s", project)
+	}
+
+	return nil
+}
+
+// printRecords prints the project of each record in text mode, and every field otherwise
+func printRecords(records []Record) error {
+	if structured() {
+		if records == nil {
+			records = []Record{}
+		}
+
+		return writeStructured(os.Stdout, records)
+	}
+
+	for _, r := range records {
+		fmt.Println(r.Project)
+	}
+
+	return nil
+}
+
+// printResults writes the results collected from a write command in structured modes
+func printResults() error {
+	if !structured() || results == nil {
+		return nil
+	}
+
+	return writeStructured(os.Stdout, results)
+}
+
+func printGitOutput(output, project string) {
+	if structured() {
+		results = append(results, Result{Project: project, Output: output})
+		return
+	}
+
+	color.Green(project)
+	fmt.Println(output)
+}
+
+// printRunnerResult prints the output of a command run in a project and how it exited
+func printRunnerResult(result runner.Result) {
+	if structured() {
+		r := Result{Project: result.Project, Output: result.Output, ExitCode: result.ExitCode, Skipped: result.Skipped}
+		if result.Err != nil {
+			r.Error = result.Err.Error()
+		}
+
+		results = append(results, r)
+		return
+	}
+
+	color.Green(result.Project)
+	fmt.Print(result.Output)
+
+	switch {
+	case result.Skipped != "":
+		color.Yellow("skipped as %s", result.Skipped)
+	case result.Err != nil:
+		color.Red(result.Err.Error())
+	case result.ExitCode != 0:
+		color.Red("exit code %d", result.ExitCode)
+	}
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/LGUG2Z/story/node"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
 )
@@ -11,6 +14,11 @@ func PinCmd(fs afero.Fs) cli.Command {
 	return cli.Command{
 		Name:  "pin",
 		Usage: "Pins code in the current story",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "registry", EnvVar: "STORY_REGISTRY", Usage: "publish prerelease versions to this npm registry instead of pinning git commit hashes"},
+			cli.StringFlag{Name: "registry-token", EnvVar: "NPM_TOKEN", Usage: "token to authenticate with the npm registry"},
+			cli.BoolFlag{Name: "tags", Usage: "create and push story/<name>/<n> tags and pin dependencies to them instead of commit hashes"},
+		},
 		Action: cli.ActionFunc(func(c *cli.Context) error {
 			if !isStory {
 				return ErrNotWorkingOnAStory
@@ -25,31 +33,93 @@ func PinCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
+			// Pinning to commit hashes is impossible when story projects depend on each other
+			_, cycles, err := storyCycles(fs, story)
+			if err != nil {
+				return err
+			}
+
+			if len(cycles) > 0 {
+				return ErrStoryProjectsFormACycle(cycles[0])
+			}
+
+			if c.String("registry") != "" && c.Bool("tags") {
+				return ErrPinModesAreExclusive
+			}
+
+			if c.String("registry") != "" {
+				if err := publishPrereleases(fs, story, getRegistryClient(c.String("registry"), c.String("registry-token"))); err != nil {
+					return err
+				}
+
+				return story.Write(fs)
+			}
+
+			if c.Bool("tags") {
+				if story.Tags, err = tagStoryProjects(story); err != nil {
+					return err
+				}
+
+				if err := story.Write(fs); err != nil {
+					return err
+				}
+			}
+
 			var projectList []string
 			for project := range story.Projects {
 				projectList = append(projectList, project)
 			}
 
-			// Update all of the package.json files where any other added project is used
+			// Update all of the dependency manifests where any other added project is used
 			for project := range story.Projects {
 				if ignore[project] {
 					continue
 				}
 
-				p := node.PackageJSON{}
-				if err := p.Load(fs, project); err != nil {
-					return err
+				p, err := ecosystem.Load(fs, project)
+				if err != nil {
+					if err := keepGoingOn(project, "pin", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				// Dependencies which can't be referenced by a tag are still pinned to commit hashes
+				if pinner, ok := p.(ecosystem.RefPinner); ok && c.Bool("tags") {
+					pinner.SetDependencyBranchesToRefs(story.Tags, projectList...)
+				} else if err := p.SetDependencyBranchesToCommitHashes(story, projectList...); err != nil {
+					if err := keepGoingOn(project, "pin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				p.SetPrivateDependencyBranchesToCommitHashes(story, projectList...)
 				if err := p.Write(fs, project); err != nil {
-					return err
+					if err := keepGoingOn(project, "pin", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				recordOriginals(story, project, p)
+
+				files, err := ecosystem.ManifestFiles(fs, project, p)
+				if err != nil {
+					if err := keepGoingOn(project, "pin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				printGitOutput("package.json updated", project)
+				printGitOutput(fmt.Sprintf("%s updated", strings.Join(files, ", ")), project)
 			}
 
-			return nil
+			// Keep the versions the dependencies had before the story so they can be restored
+			return story.Write(fs)
 		}),
 	}
 }
@@ -2,12 +2,10 @@ package cli
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/fatih/color"
 	"github.com/google/go-github/github"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
@@ -64,33 +62,38 @@ func PRCmd(fs afero.Fs) cli.Command {
 							switch err.Error() {
 							// This should never actually happen
 							case ErrCouldNotFindOpenPullRequest(story.Name).Error():
-								fmt.Println(err.Error())
+								printGitOutput(err.Error(), project)
 								continue ProjectLoop
 							// If there is an error making the call to get all pull requests
 							default:
-								return err
+								if err := keepGoingOn(project, "pr", err); err != nil {
+									return err
+								}
+
+								continue ProjectLoop
 							}
 						}
 
 						// Output the URL of the existing open pull request
-						color.Green(project)
-						fmt.Println(*pullRequest.HTMLURL)
+						printGitOutput(*pullRequest.HTMLURL, project)
 						continue ProjectLoop
 					}
 
 					// If there is a branch with no difference from master
 					if strings.Contains(err.Error(), "No commits between") {
-						color.Green(project)
-						fmt.Println("branch is identical to master, can't open a pull request yet")
+						printGitOutput("branch is identical to master, can't open a pull request yet", project)
 						continue ProjectLoop
 					}
 
 					// Any other error from the call
-					return err
+					if err := keepGoingOn(project, "pr", err); err != nil {
+						return err
+					}
+
+					continue ProjectLoop
 				}
 
-				color.Green(project)
-				fmt.Println(*pullRequest.HTMLURL)
+				printGitOutput(*pullRequest.HTMLURL, project)
 
 				time.Sleep(1 * time.Second)
 			}
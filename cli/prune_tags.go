@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func PruneTagsCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:      "prune-tags",
+		Usage:     "Deletes the superseded pin tags of merged stories",
+		ArgsUsage: "[stories...]",
+		Action: cli.ActionFunc(func(c *cli.Context) error {
+			// Every archived story has been prepared for merge
+			names := c.Args()
+			if !c.Args().Present() {
+				archived, err := afero.Glob(fs, "story/*.json")
+				if err != nil {
+					return err
+				}
+
+				for _, filename := range archived {
+					names = append(names, strings.TrimSuffix(filepath.Base(filename), ".json"))
+				}
+			}
+
+			for _, name := range names {
+				story, err := manifest.LoadStoryFromBranchName(fs, name)
+				if err != nil {
+					return fmt.Errorf("%s: %s", name, err)
+				}
+
+				if err := pruneStoryTags(fs, story); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}),
+	}
+}
@@ -54,7 +54,11 @@ func PushCmd(fs afero.Fs) cli.Command {
 			for project := range story.Projects {
 				output, err := git.Push(git.PushOpts{Remote: "origin", Branch: branch, Project: project})
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "push", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
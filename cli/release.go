@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/node"
+	"github.com/LGUG2Z/story/release"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func ReleaseCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:      "release",
+		Usage:     "Releases new versions of the projects in a merged story and their dependents",
+		ArgsUsage: "[story]",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{Name: "major", Usage: "release a major version of a story project"},
+			cli.StringSliceFlag{Name: "minor", Usage: "release a minor version of a story project"},
+			cli.StringSliceFlag{Name: "patch", Usage: "release a patch version of a story project"},
+		},
+		Action: cli.ActionFunc(func(c *cli.Context) error {
+			if isStory {
+				return ErrReleaseFromTrunk
+			}
+
+			if !c.Args().Present() {
+				return ErrCommandRequiresAnArgument
+			}
+
+			story, err := manifest.LoadStoryFromBranchName(fs, c.Args().First())
+			if err != nil {
+				return err
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			// Bumps given as flags take precedence over conventional commit messages
+			bumps := make(map[string]release.Bump)
+			for _, flag := range []struct {
+				name string
+				bump release.Bump
+			}{{"patch", release.Patch}, {"minor", release.Minor}, {"major", release.Major}} {
+				for _, project := range c.StringSlice(flag.name) {
+					if _, exists := story.Projects[project]; !exists {
+						return ErrProjectNotInStory(project, story.Name)
+					}
+
+					bumps[project] = flag.bump
+				}
+			}
+
+			for project := range story.Projects {
+				if _, chosen := bumps[project]; chosen {
+					continue
+				}
+
+				tag, err := git.LatestTag(project, "v*")
+				if err != nil {
+					return err
+				}
+
+				messages, err := git.Messages(project, tag)
+				if err != nil {
+					return err
+				}
+
+				bumps[project] = release.FromMessages(messages)
+			}
+
+			// Release dependencies before the dependents that consume them
+			releases := make(map[string]string)
+			for _, wave := range g.Waves() {
+				for _, project := range wave {
+					// Specifiers which can't point at a release, such as local paths, are left as
+					// they are
+					specifiers := make(map[string]string)
+					for _, e := range g.Dependencies(project) {
+						version, released := releases[e.To]
+						if !released {
+							continue
+						}
+
+						if specifier, ok := release.UpdateRange(e.Specifier, version); ok && specifier != e.Specifier {
+							specifiers[g.Packages[e.To]] = specifier
+						}
+					}
+
+					// Dependents get at least a patch release for their updated dependencies
+					bump := bumps[project]
+					if len(specifiers) > 0 && bump == release.None {
+						bump = release.Patch
+					}
+
+					if bump == release.None || ignore[project] {
+						continue
+					}
+
+					a, err := ecosystem.Detect(fs, project)
+					if err != nil {
+						return err
+					}
+
+					if a.Ecosystem() != "node" {
+						continue
+					}
+
+					p := node.PackageJSON{}
+					if err := p.Load(fs, project); err != nil {
+						return err
+					}
+
+					for dependency, specifier := range specifiers {
+						p.SetDependency(dependency, specifier)
+					}
+
+					version, err := release.Increment(p.Version(), bump)
+					if err != nil {
+						return fmt.Errorf("%s: %s", project, err)
+					}
+
+					p.SetVersion(version)
+					if err := p.Write(fs, project); err != nil {
+						return err
+					}
+
+					if _, err := git.Add(git.AddOpts{Project: project, Files: []string{"package.json"}}); err != nil {
+						return err
+					}
+
+					message := fmt.Sprintf("[story release] Release %s (%s) from '%s'", release.Tag(version), bump, story.Name)
+					if _, err := git.Commit(git.CommitOpts{Project: project, Messages: []string{message}}); err != nil {
+						return err
+					}
+
+					output, err := git.Tag(git.TagOpts{Project: project, Name: release.Tag(version), Message: message})
+					if err != nil {
+						return err
+					}
+
+					printGitOutput(output, project)
+					releases[project] = version
+				}
+			}
+
+			if len(releases) == 0 {
+				return nil
+			}
+
+			// Record the released versions in the archived story
+			story.Releases = releases
+			storyNameWithoutSlash := strings.ReplaceAll(story.Name, "/", "-")
+			if err := story.WriteToLocation(fs, fmt.Sprintf("story/%s.json", storyNameWithoutSlash)); err != nil {
+				return err
+			}
+
+			if _, err := git.Add(git.AddOpts{Project: "story", Files: []string{fmt.Sprintf("%s.json", storyNameWithoutSlash)}}); err != nil {
+				return err
+			}
+
+			var releaseMessages []string
+			for _, project := range g.Projects {
+				if version, released := releases[project]; released {
+					releaseMessages = append(releaseMessages, fmt.Sprintf("%s %s", project, release.Tag(version)))
+				}
+			}
+
+			messages := []string{fmt.Sprintf("[story release] Recording releases from '%s'", story.Name), strings.Join(releaseMessages, "\n")}
+			output, err := git.Commit(git.CommitOpts{Messages: messages})
+			if err != nil {
+				return err
+			}
+
+			printGitOutput(output, metarepo)
+
+			return nil
+		}),
+	}
+}
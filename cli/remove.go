@@ -1,9 +1,9 @@
 package cli
 
 import (
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/LGUG2Z/story/node"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
 )
@@ -17,7 +17,8 @@ func RemoveCmd(fs afero.Fs) cli.Command {
 				return ErrNotWorkingOnAStory
 			}
 
-			if !c.Args().Present() {
+			projects := projectArgs(c)
+			if len(projects) == 0 {
 				return ErrCommandRequiresAnArgument
 			}
 
@@ -26,13 +27,16 @@ func RemoveCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
-			for _, project := range c.Args() {
+			for _, project := range projects {
 				// Remove project from manifest
 				story.RemoveFromManifest(project)
 
 				// Remove it from the blast radius map
 				delete(story.BlastRadius, project)
 
+				// Its story branch is deleted along with its rewritten dependencies
+				delete(story.Originals, project)
+
 				// Delete the branch
 				output, err := git.DeleteBranch(git.DeleteBranchOpts{
 					Branch:  story.Name,
@@ -41,7 +45,11 @@ func RemoveCmd(fs afero.Fs) cli.Command {
 				})
 
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "remove", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
@@ -64,26 +72,34 @@ func RemoveCmd(fs afero.Fs) cli.Command {
 				projectList = append(projectList, project)
 			}
 
-			// Update all of the package.json files where any removed project is used
+			// Update all of the dependency manifests where any removed project is used
 			for project := range story.Projects {
 				if ignore[project] {
 					continue
 				}
 
-				p := node.PackageJSON{}
-				if err := p.Load(fs, project); err != nil {
-					return err
+				p, err := ecosystem.Load(fs, project)
+				if err != nil {
+					if err := keepGoingOn(project, "remove", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				for _, toReset := range projects {
+					p.ResetDependencyBranches(toReset, story.Name)
 				}
 
-				for _, toReset := range c.Args() {
-					p.ResetPrivateDependencyBranches(toReset, story.Name)
-					if err := p.Write(fs, project); err != nil {
+				restoreOriginals(story, project, p, projects...)
+				if err := p.Write(fs, project); err != nil {
+					if err := keepGoingOn(project, "remove", err); err != nil {
 						return err
 					}
 				}
 			}
 
-			return nil
+			return story.Write(fs)
 		},
 	}
 }
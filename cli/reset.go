@@ -28,7 +28,11 @@ func ResetCmd(fs afero.Fs) cli.Command {
 			for project := range story.Projects {
 				output, err := git.CheckoutBranch(git.CheckoutBranchOpts{Branch: trunk, Project: project})
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "checkout", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/runner"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func TestCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "test",
+		Usage: "Runs the tests of every project in the current story and its blast radius in dependency order",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "junit", Usage: "write a JUnit XML summary of the results to a file"},
+			cli.IntFlag{Name: "parallel", Usage: "maximum number of projects to test at once (default: number of CPUs)"},
+		},
+		Action: func(c *cli.Context) error {
+			if dryrun.Enabled() {
+				return ErrDryRunNotSupported("test")
+			}
+
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			selected := make(map[string]bool)
+			for _, project := range storyProjects(story, true) {
+				cloned, err := afero.DirExists(fs, project)
+				if err != nil {
+					return err
+				}
+
+				if !cloned {
+					color.Yellow("skipping %s as it has not been cloned", project)
+					continue
+				}
+
+				selected[project] = true
+			}
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			if err != nil {
+				return err
+			}
+
+			sub := g.Subgraph(selected)
+			waves := testWaves(sub, selected)
+
+			// Projects are not tested when anything they depend on failed or was skipped
+			var results []runner.Result
+			var failed []string
+			broken := make(map[string]bool)
+			for _, wave := range waves {
+				var tasks []runner.Task
+				var skipped []runner.Result
+				for _, project := range wave {
+					if dependency, ok := brokenDependency(sub, project, broken); ok {
+						skipped = append(skipped, runner.Result{Project: project, Skipped: fmt.Sprintf("%s did not pass", dependency)})
+						continue
+					}
+
+					tasks = append(tasks, runner.Task{Project: project, Command: config.TestCommand(project)})
+				}
+
+				waveResults := append(runner.Run(tasks, c.Int("parallel")), skipped...)
+				sort.Slice(waveResults, func(i, j int) bool {
+					return waveResults[i].Project < waveResults[j].Project
+				})
+
+				for _, result := range waveResults {
+					printRunnerResult(result)
+
+					if result.Failed() {
+						failed = append(failed, result.Project)
+					}
+
+					if result.Failed() || result.Skipped != "" {
+						broken[result.Project] = true
+					}
+				}
+
+				results = append(results, waveResults...)
+			}
+
+			if junit := pathFlag(c, "junit"); junit != "" {
+				var buf bytes.Buffer
+				if err := runner.WriteJUnit(&buf, story.Name, results); err != nil {
+					return err
+				}
+
+				if err := afero.WriteFile(fs, junit, buf.Bytes(), os.FileMode(0666)); err != nil {
+					return err
+				}
+			}
+
+			sort.Strings(failed)
+			return failedInProjects("test", failed)
+		},
+	}
+}
+
+// testWaves orders the selected projects into waves, with projects that are not in the
+// graph tested in the first wave as they have no dependencies to wait for
+func testWaves(sub *graph.Graph, selected map[string]bool) [][]string {
+	waves := sub.Waves()
+
+	var unordered []string
+	for project := range selected {
+		if !sub.Has(project) {
+			unordered = append(unordered, project)
+		}
+	}
+
+	if len(unordered) == 0 {
+		return waves
+	}
+
+	if len(waves) == 0 {
+		waves = [][]string{nil}
+	}
+
+	waves[0] = append(waves[0], unordered...)
+	sort.Strings(waves[0])
+
+	return waves
+}
+
+// brokenDependency returns a dependency of a project which failed or was skipped
+func brokenDependency(g *graph.Graph, project string, broken map[string]bool) (string, bool) {
+	for _, e := range g.Dependencies(project) {
+		if e.To != project && broken[e.To] {
+			return e.To, true
+		}
+	}
+
+	return "", false
+}
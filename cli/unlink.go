@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/link"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func UnlinkCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "unlink",
+		Usage: "Restores the installed node_modules of story projects linked with story link",
+		Action: cli.ActionFunc(func(c *cli.Context) error {
+			if dryrun.Enabled() {
+				return ErrDryRunNotSupported("unlink")
+			}
+
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			links, err := storyLinks(fs, story)
+			if err != nil {
+				return err
+			}
+
+			for _, l := range links {
+				removed, err := link.Remove(l)
+				if err != nil {
+					if err := keepGoingOn(l.Project, "unlink", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				if removed {
+					printGitOutput(fmt.Sprintf("unlinked node_modules/%s", l.Package), l.Project)
+				}
+			}
+
+			return nil
+		}),
+	}
+}
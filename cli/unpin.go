@@ -5,9 +5,9 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/git"
 	"github.com/LGUG2Z/story/manifest"
-	"github.com/LGUG2Z/story/node"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
 )
@@ -31,34 +31,64 @@ func UnpinCmd(fs afero.Fs) cli.Command {
 				return err
 			}
 
-			messages := []string{fmt.Sprintf("[story unpin] Unpinning package.json dependencies from '%s' [skip ci]", story.Name)}
+			messages := []string{fmt.Sprintf("[story unpin] Unpinning dependencies from '%s' [skip ci]", story.Name)}
 
-			// Unpin dependencies in package.json files from branch
+			// Restore the ranges of dependencies pinned to prerelease versions
+			if err := restorePrereleaseRanges(fs, story); err != nil {
+				return err
+			}
+
+			// Unpin dependencies in dependency manifests from branch
 			for project := range story.Projects {
 				if ignore[project] {
 					continue
 				}
 
-				p := node.PackageJSON{}
-				if err := p.Load(fs, project); err != nil {
-					return err
+				p, err := ecosystem.Load(fs, project)
+				if err != nil {
+					if err := keepGoingOn(project, "unpin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				p.ResetPrivateDependencyBranchesToMaster(story.Name)
+				p.ResetDependencyBranchesToTrunk(story.Name)
+				restoreOriginals(story, project, p)
 				if err := p.Write(fs, project); err != nil {
-					return err
+					if err := keepGoingOn(project, "unpin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				// Stage the modified package.json file
-				_, err := git.Add(git.AddOpts{Project: project, Files: []string{"package.json"}})
+				files, err := ecosystem.ManifestFiles(fs, project, p)
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "unpin", err); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				// Stage the modified dependency manifests
+				if _, err := git.Add(git.AddOpts{Project: project, Files: files}); err != nil {
+					if err := keepGoingOn(project, "unpin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
-				// Commit the modified package.json file
+				// Commit the modified dependency manifests
 				output, err := git.Commit(git.CommitOpts{Project: project, Messages: messages})
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "unpin", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(output, project)
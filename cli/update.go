@@ -37,7 +37,11 @@ func UpdateCmd(fs afero.Fs) cli.Command {
 					Remote:  "origin",
 					Project: project,
 				}); err != nil {
-					return err
+					if err := keepGoingOn(project, "fetch", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				mergeOutput, err := git.Merge(git.MergeOpts{
@@ -47,7 +51,11 @@ func UpdateCmd(fs afero.Fs) cli.Command {
 				})
 
 				if err != nil {
-					return err
+					if err := keepGoingOn(project, "merge", err); err != nil {
+						return err
+					}
+
+					continue
 				}
 
 				printGitOutput(mergeOutput, project)
@@ -3,20 +3,26 @@ package cli
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/ecosystem"
 	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/graph"
+	"github.com/LGUG2Z/story/link"
 	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/node"
+	"github.com/LGUG2Z/story/registry"
 	"github.com/fatih/color"
 	"github.com/google/go-github/github"
 	"github.com/spf13/afero"
+	"github.com/urfave/cli"
 	"golang.org/x/oauth2"
 )
 
-func printGitOutput(output, project string) {
-	color.Green(project)
-	fmt.Println(output)
-}
-
 func ensureProjectIsCloned(fs afero.Fs, story *manifest.Story, project string) error {
 	exists, err := afero.DirExists(fs, project)
 	if err != nil {
@@ -35,10 +41,479 @@ func ensureProjectIsCloned(fs afero.Fs, story *manifest.Story, project string) e
 	return nil
 }
 
+// blastRadiusDepth returns the depth to calculate the blast radius of a story to, which is
+// kept on the story when given so that later recalculations use the same depth
+func blastRadiusDepth(c *cli.Context, story *manifest.Story) int {
+	if c.IsSet("depth") {
+		story.Depth = c.Int("depth")
+	}
+
+	return story.Depth
+}
+
+func refreshBlastRadius(fs afero.Fs, story *manifest.Story, depth int) (gained, lost []string, err error) {
+	before := make(map[string]bool)
+	for project, isArtifact := range story.Artifacts {
+		before[project] = isArtifact
+	}
+
+	// Recalculate from scratch so that projects no longer in the story are dropped, keeping
+	// the previous blast radius if it can't be recalculated
+	previous := story.BlastRadius
+	story.BlastRadius = make(map[string][]string)
+
+	b := graph.NewCalculator()
+	b.Depth = depth
+	for project := range story.Projects {
+		if err := story.CalculateBlastRadiusForProject(fs, b, project); err != nil {
+			story.BlastRadius = previous
+			return nil, nil, err
+		}
+	}
+
+	story.MapBlastRadiusToArtifacts()
+
+	for project, isArtifact := range story.Artifacts {
+		if isArtifact && !before[project] {
+			gained = append(gained, project)
+		}
+
+		if !isArtifact && before[project] {
+			lost = append(lost, project)
+		}
+	}
+
+	sort.Strings(gained)
+	sort.Strings(lost)
+
+	return gained, lost, nil
+}
+
+// storyCycles returns the graph of the story projects and the groups of them which depend
+// on each other, reading only the manifests of the story projects
+func storyCycles(fs afero.Fs, story *manifest.Story) (*graph.Graph, [][]string, error) {
+	var projects []string
+	for project := range story.Projects {
+		projects = append(projects, project)
+	}
+
+	if len(projects) == 0 {
+		return nil, nil, nil
+	}
+
+	sort.Strings(projects)
+
+	g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile, Projects: projects})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return g, g.Cycles(), nil
+}
+
+// publishPrereleases publishes the node projects in the story to a registry as prerelease
+// versions in dependency order, rewriting dependencies on each other to the exact versions
+func publishPrereleases(fs afero.Fs, story *manifest.Story, client *registry.Client) error {
+	g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+	if err != nil {
+		return err
+	}
+
+	inStory := make(map[string]bool)
+	for project := range story.Projects {
+		inStory[project] = true
+	}
+
+	// Ranges recorded by an earlier pin are kept so that unpin can always restore them
+	if story.Prerelease == nil {
+		story.Prerelease = &manifest.Prerelease{Ranges: make(map[string]map[string]string)}
+	}
+
+	story.Prerelease.Registry = client.URL
+	story.Prerelease.Versions = make(map[string]string)
+
+	for _, wave := range g.Subgraph(inStory).Waves() {
+		for _, project := range wave {
+			a, err := ecosystem.Detect(fs, project)
+			if err != nil {
+				return err
+			}
+
+			if a.Ecosystem() != "node" {
+				continue
+			}
+
+			hash, exists := story.Hashes[project]
+			if !exists {
+				return ErrNoCommitHash(project)
+			}
+
+			p := node.PackageJSON{}
+			if err := p.Load(fs, project); err != nil {
+				return err
+			}
+
+			for _, e := range g.Dependencies(project) {
+				version, published := story.Prerelease.Versions[e.To]
+				if !published {
+					continue
+				}
+
+				pkg := g.Packages[e.To]
+				original, exists := p.SetDependency(pkg, version)
+				if !exists {
+					continue
+				}
+
+				if story.Prerelease.Ranges[project] == nil {
+					story.Prerelease.Ranges[project] = make(map[string]string)
+				}
+
+				if _, recorded := story.Prerelease.Ranges[project][pkg]; !recorded {
+					story.Prerelease.Ranges[project][pkg] = original
+				}
+			}
+
+			if !ignore[project] {
+				if err := p.Write(fs, project); err != nil {
+					return err
+				}
+			}
+
+			version := registry.PrereleaseVersion(p.Version(), story.Name, hash)
+			if err := client.Publish(project, hash, &p, version, registry.Tag(story.Name)); err != nil {
+				return err
+			}
+
+			story.Prerelease.Versions[project] = version
+			printGitOutput(fmt.Sprintf("published %s@%s", g.Packages[project], version), project)
+		}
+	}
+
+	return nil
+}
+
+// restorePrereleaseRanges rewrites dependencies on published prerelease versions back
+// to the ranges they had before the story was pinned
+func restorePrereleaseRanges(fs afero.Fs, story *manifest.Story) error {
+	if story.Prerelease == nil {
+		return nil
+	}
+
+	for project, ranges := range story.Prerelease.Ranges {
+		if ignore[project] {
+			continue
+		}
+
+		p := node.PackageJSON{}
+		if err := p.Load(fs, project); err != nil {
+			return err
+		}
+
+		for pkg, original := range ranges {
+			p.SetDependency(pkg, original)
+		}
+
+		if err := p.Write(fs, project); err != nil {
+			return err
+		}
+	}
+
+	story.Prerelease = nil
+
+	return nil
+}
+
+// recordOriginals records the versions of the dependencies a project's adapter rewrote
+// for the story, keeping the versions recorded when they were first rewritten
+func recordOriginals(story *manifest.Story, project string, a ecosystem.Adapter) {
+	restorer, ok := a.(ecosystem.Restorer)
+	if !ok {
+		return
+	}
+
+	for dependency, original := range restorer.Originals() {
+		if story.Originals == nil {
+			story.Originals = make(map[string]map[string]string)
+		}
+
+		if story.Originals[project] == nil {
+			story.Originals[project] = make(map[string]string)
+		}
+
+		if _, recorded := story.Originals[project][dependency]; !recorded {
+			story.Originals[project][dependency] = original
+		}
+	}
+}
+
+// restoreOriginals puts back the recorded versions of a project's dependencies on some
+// story projects, or on all of them if none are given, and forgets them
+func restoreOriginals(story *manifest.Story, project string, a ecosystem.Adapter, dependencies ...string) {
+	restorer, ok := a.(ecosystem.Restorer)
+	if !ok {
+		return
+	}
+
+	originals := story.Originals[project]
+	if len(dependencies) > 0 {
+		originals = make(map[string]string)
+		for _, dependency := range dependencies {
+			if original, recorded := story.Originals[project][dependency]; recorded {
+				originals[dependency] = original
+			}
+		}
+	}
+
+	restorer.Restore(originals)
+
+	for dependency := range originals {
+		delete(story.Originals[project], dependency)
+	}
+
+	if len(story.Originals[project]) == 0 {
+		delete(story.Originals, project)
+	}
+}
+
+// tagStoryProjects creates the next story/<name>/<n> tag at the pinned commit hash of
+// every project in the story and pushes it. The next number follows the tags on origin
+// as well as local ones, and if any project can't be tagged, the tags already created
+// for the others are deleted again.
+func tagStoryProjects(story *manifest.Story) (map[string]string, error) {
+	var projects []string
+	for project := range story.Projects {
+		projects = append(projects, project)
+	}
+
+	sort.Strings(projects)
+
+	tags := make(map[string]string)
+	pushed := make(map[string]bool)
+	for _, project := range projects {
+		hash, exists := story.Hashes[project]
+		if !exists {
+			return nil, untagStoryProjects(tags, pushed, ErrNoCommitHash(project))
+		}
+
+		pattern := fmt.Sprintf("%s*", story.TagPrefix())
+		existing, err := git.Tags(project, pattern)
+		if err != nil {
+			return nil, untagStoryProjects(tags, pushed, err)
+		}
+
+		remote, err := git.RemoteTags(project, "origin", pattern)
+		if err != nil {
+			return nil, untagStoryProjects(tags, pushed, err)
+		}
+
+		next := 1
+		for _, tag := range append(existing, remote...) {
+			if n, err := strconv.Atoi(strings.TrimPrefix(tag, story.TagPrefix())); err == nil && n >= next {
+				next = n + 1
+			}
+		}
+
+		tag := fmt.Sprintf("%s%d", story.TagPrefix(), next)
+		message := fmt.Sprintf("[story pin] Pinning '%s'", story.Name)
+		if _, err := git.Tag(git.TagOpts{Project: project, Name: tag, Commit: hash, Message: message}); err != nil {
+			return nil, untagStoryProjects(tags, pushed, err)
+		}
+
+		tags[project] = tag
+
+		output, err := git.PushTags(git.PushTagsOpts{Project: project, Remote: "origin", Tags: []string{tag}})
+		if err != nil {
+			return nil, untagStoryProjects(tags, pushed, err)
+		}
+
+		pushed[project] = true
+		printGitOutput(output, project)
+	}
+
+	return tags, nil
+}
+
+// untagStoryProjects deletes the tags created by tagStoryProjects before it failed, from
+// origin if they were pushed, and reports any which are left behind
+func untagStoryProjects(tags map[string]string, pushed map[string]bool, cause error) error {
+	var projects []string
+	for project := range tags {
+		projects = append(projects, project)
+	}
+
+	sort.Strings(projects)
+
+	for _, project := range projects {
+		tag := tags[project]
+		if pushed[project] {
+			if _, err := git.DeleteTags(git.DeleteTagsOpts{Project: project, Remote: "origin", Tags: []string{tag}}); err != nil {
+				color.Red("%s: could not delete %s from origin: %s", project, tag, err)
+			}
+		}
+
+		if _, err := git.DeleteTags(git.DeleteTagsOpts{Project: project, Tags: []string{tag}}); err != nil {
+			color.Red("%s: could not delete %s: %s", project, tag, err)
+			continue
+		}
+
+		printGitOutput(fmt.Sprintf("deleted %s", tag), project)
+	}
+
+	return cause
+}
+
+// pruneStoryTags deletes the pin tags of a story which have been superseded by a later pin
+// from every cloned project and its origin. The tags recorded in the story are kept, as
+// the manifests on trunk still refer to them once the story is merged.
+func pruneStoryTags(fs afero.Fs, story *manifest.Story) error {
+	pattern := fmt.Sprintf("%s*", story.TagPrefix())
+	for project := range story.Projects {
+		exists, err := afero.DirExists(fs, project)
+		if err != nil {
+			if err := keepGoingOn(project, "prune-tags", err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !exists {
+			continue
+		}
+
+		local, err := git.Tags(project, pattern)
+		if err != nil {
+			if err := keepGoingOn(project, "prune-tags", err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		local = supersededTags(local, story.Tags[project])
+
+		if _, err := git.DeleteTags(git.DeleteTagsOpts{Project: project, Tags: local}); err != nil {
+			if err := keepGoingOn(project, "prune-tags", err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		remote, err := git.RemoteTags(project, "origin", pattern)
+		if err != nil {
+			if err := keepGoingOn(project, "prune-tags", err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		remote = supersededTags(remote, story.Tags[project])
+
+		if _, err := git.DeleteTags(git.DeleteTagsOpts{Project: project, Remote: "origin", Tags: remote}); err != nil {
+			if err := keepGoingOn(project, "prune-tags", err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		printGitOutput(fmt.Sprintf("pruned %d local and %d remote tags", len(local), len(remote)), project)
+	}
+
+	return nil
+}
+
+// supersededTags returns the tags other than the current pin of a project
+func supersededTags(tags []string, current string) []string {
+	var superseded []string
+	for _, tag := range tags {
+		if tag != current {
+			superseded = append(superseded, tag)
+		}
+	}
+
+	return superseded
+}
+
+// storyLinks returns a link for every dependency of a node project in the story on
+// another node project in the story, excluding projects in the .storyignore file
+func storyLinks(fs afero.Fs, story *manifest.Story) ([]link.Link, error) {
+	g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+	if err != nil {
+		return nil, err
+	}
+
+	inStory := make(map[string]bool)
+	for project := range story.Projects {
+		a, err := ecosystem.Detect(fs, project)
+		if err == nil && a.Ecosystem() == "node" {
+			inStory[project] = true
+		}
+	}
+
+	sub := g.Subgraph(inStory)
+
+	var links []link.Link
+	for _, project := range sub.Projects {
+		if ignore[project] {
+			continue
+		}
+
+		for _, e := range sub.Dependencies(project) {
+			links = append(links, link.Link{Project: project, Package: sub.Packages[e.To], Target: e.To})
+		}
+	}
+
+	return links, nil
+}
+
+// storyProjects returns the sorted projects in the story, optionally including every
+// project in its blast radius
+func storyProjects(story *manifest.Story, blastRadius bool) []string {
+	selected := make(map[string]bool)
+	for project := range story.Projects {
+		selected[project] = true
+	}
+
+	if blastRadius {
+		for _, br := range story.BlastRadius {
+			for _, project := range br {
+				selected[project] = true
+			}
+		}
+	}
+
+	var projects []string
+	for project := range selected {
+		projects = append(projects, project)
+	}
+
+	sort.Strings(projects)
+
+	return projects
+}
+
+// projectArgs returns the projects given as arguments to a command, or the project it was
+// run from if there are none
+func projectArgs(c *cli.Context) []string {
+	if c.Args().Present() {
+		return c.Args()
+	}
+
+	if currentProject != "" {
+		return []string{currentProject}
+	}
+
+	return nil
+}
+
 func getGitHubClient(ctx context.Context, token string) *github.Client {
 	return github.NewClient(
 		oauth2.NewClient(
-			ctx,
+			dryRunContext(ctx),
 			oauth2.StaticTokenSource(
 				&oauth2.Token{AccessToken: token},
 			),
@@ -46,6 +521,15 @@ func getGitHubClient(ctx context.Context, token string) *github.Client {
 	)
 }
 
+func getRegistryClient(registryURL, token string) *registry.Client {
+	client := registry.NewClient(registryURL, token)
+	if dryrun.Enabled() {
+		client.HTTP = dryRunHTTPClient()
+	}
+
+	return client
+}
+
 func getOpenPullRequest(client *github.Client, ctx context.Context, story *manifest.Story, project string) (*github.PullRequest, error) {
 	pullRequests, _, err := client.PullRequests.List(ctx, story.Orgranisation, project, &github.PullRequestListOptions{
 		State: "open",
@@ -83,3 +567,15 @@ func getClosedPullRequest(client *github.Client, ctx context.Context, story *man
 
 	return nil, ErrCouldNotFindClosedPullRequest(story.Name)
 }
+
+// pathFlag returns the value of a path flag, resolving a relative path given by the user
+// against the directory story was run from rather than the metarepo. Defaults are left
+// relative to the metarepo.
+func pathFlag(c *cli.Context, name string) string {
+	path := c.String(name)
+	if path == "" || !c.IsSet(name) || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(workingDirectory, path)
+}
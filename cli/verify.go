@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+	"github.com/urfave/cli"
+)
+
+func VerifyCmd(fs afero.Fs) cli.Command {
+	return cli.Command{
+		Name:  "verify",
+		Usage: "Checks that the projects in the current story can be pinned",
+		Action: func(c *cli.Context) error {
+			if !isStory {
+				return ErrNotWorkingOnAStory
+			}
+
+			if c.Args().Present() {
+				return ErrCommandTakesNoArguments
+			}
+
+			story, err := manifest.LoadStory(fs)
+			if err != nil {
+				return err
+			}
+
+			// Commit hashes can't be pinned consistently between projects in a cycle
+			g, cycles, err := storyCycles(fs, story)
+			if err != nil {
+				return err
+			}
+
+			if structured() {
+				if err := writeStructured(os.Stdout, cycleRecords(g, cycles)); err != nil {
+					return err
+				}
+			} else if len(cycles) == 0 {
+				fmt.Println("no problems found")
+			} else {
+				for _, cycle := range cycles {
+					color.Red(ErrStoryProjectsFormACycle(cycle).Error())
+				}
+			}
+
+			if len(cycles) == 0 {
+				return nil
+			}
+
+			return ErrStoryProjectsFormACycle(cycles[0])
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/LGUG2Z/story/build"
+)
+
+// Image is the container image built for an artifact
+type Image struct {
+	Project    string
+	Repository string
+	Tag        string
+}
+
+// ImageTag is a deterministic tag for the image of an artifact, made of the short commit
+// of the artifact and a digest of its commit and the commits of its story dependencies
+func ImageTag(project string, commits map[string]string) string {
+	commit := commits[project]
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	return fmt.Sprintf("%s-%s", commit, build.Key(project, commits)[:8])
+}
+
+// HelmImage is the image section of the Helm values of an artifact
+type HelmImage struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Tag        string `json:"tag" yaml:"tag"`
+}
+
+type HelmValues struct {
+	Image HelmImage `json:"image" yaml:"image"`
+}
+
+// Helm returns Helm values with the image of every artifact, keyed by project
+func Helm(images []Image) map[string]HelmValues {
+	values := make(map[string]HelmValues)
+	for _, image := range images {
+		values[image.Project] = HelmValues{Image: HelmImage{Repository: image.Repository, Tag: image.Tag}}
+	}
+
+	return values
+}
+
+// KustomizeImage is an entry of the images list of a kustomization
+type KustomizeImage struct {
+	Name    string `json:"name" yaml:"name"`
+	NewName string `json:"newName,omitempty" yaml:"newName,omitempty"`
+	NewTag  string `json:"newTag" yaml:"newTag"`
+}
+
+type Kustomization struct {
+	Images []KustomizeImage `json:"images" yaml:"images"`
+}
+
+// Kustomize returns a kustomization overriding the image of every artifact, sorted by project
+func Kustomize(images []Image) Kustomization {
+	k := Kustomization{Images: []KustomizeImage{}}
+	for _, image := range images {
+		i := KustomizeImage{Name: image.Project, NewTag: image.Tag}
+		if image.Repository != image.Project {
+			i.NewName = image.Repository
+		}
+
+		k.Images = append(k.Images, i)
+	}
+
+	sort.Slice(k.Images, func(i, j int) bool {
+		return k.Images[i].Name < k.Images[j].Name
+	})
+
+	return k
+}
@@ -0,0 +1,46 @@
+package deploy_test
+
+import (
+	"github.com/LGUG2Z/story/deploy"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deploy", func() {
+	Describe("Tagging images", func() {
+		It("Should start with the short commit of the artifact", func() {
+			tag := deploy.ImageTag("api", map[string]string{"api": "1111111111"})
+			Expect(tag).To(HavePrefix("1111111-"))
+			Expect(tag).To(HaveLen(16))
+		})
+
+		It("Should change when a story dependency changes", func() {
+			a := deploy.ImageTag("api", map[string]string{"api": "1111111111", "lib-1": "2222222222"})
+			b := deploy.ImageTag("api", map[string]string{"api": "1111111111", "lib-1": "3333333333"})
+
+			Expect(a).To(HavePrefix("1111111-"))
+			Expect(a).NotTo(Equal(b))
+		})
+	})
+
+	Describe("Generating descriptors", func() {
+		images := []deploy.Image{
+			{Project: "web", Repository: "web", Tag: "2222222-bbbbbbbb"},
+			{Project: "api", Repository: "registry.test-org.com/api", Tag: "1111111-aaaaaaaa"},
+		}
+
+		It("Should key Helm values by project", func() {
+			Expect(deploy.Helm(images)).To(Equal(map[string]deploy.HelmValues{
+				"api": {Image: deploy.HelmImage{Repository: "registry.test-org.com/api", Tag: "1111111-aaaaaaaa"}},
+				"web": {Image: deploy.HelmImage{Repository: "web", Tag: "2222222-bbbbbbbb"}},
+			}))
+		})
+
+		It("Should only rename kustomize images when they are in a registry", func() {
+			Expect(deploy.Kustomize(images)).To(Equal(deploy.Kustomization{Images: []deploy.KustomizeImage{
+				{Name: "api", NewName: "registry.test-org.com/api", NewTag: "1111111-aaaaaaaa"},
+				{Name: "web", NewTag: "2222222-bbbbbbbb"},
+			}}))
+		})
+	})
+})
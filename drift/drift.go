@@ -0,0 +1,122 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/node"
+	"github.com/fatih/color"
+	"github.com/spf13/afero"
+)
+
+// Usage is a specifier of a dependency and the projects which declare it
+type Usage struct {
+	Specifier string   `json:"specifier" yaml:"specifier"`
+	Projects  []string `json:"projects" yaml:"projects"`
+}
+
+// Dependency is an external dependency which is declared with more than one specifier
+type Dependency struct {
+	Name   string  `json:"name" yaml:"name"`
+	Usages []Usage `json:"usages" yaml:"usages"`
+}
+
+// Find loads the package.json files of projects and returns every external dependency
+// declared with more than one specifier. Packages provided by metarepo projects are
+// excluded, as their specifiers are managed by story itself.
+func Find(fs afero.Fs, projects []string, internal map[string]bool) ([]Dependency, error) {
+	usages := make(map[string]map[string][]string)
+	for _, project := range projects {
+		p := node.PackageJSON{}
+		if err := p.Load(fs, project); err != nil {
+			return nil, fmt.Errorf("%s: %s", project, err)
+		}
+
+		for _, deps := range []map[string]string{p.Dependencies, p.DevDependencies} {
+			for name, specifier := range deps {
+				if internal[name] {
+					continue
+				}
+
+				if usages[name] == nil {
+					usages[name] = make(map[string][]string)
+				}
+
+				usages[name][specifier] = appendUnique(usages[name][specifier], project)
+			}
+		}
+	}
+
+	var drifted []Dependency
+	for name, specifiers := range usages {
+		if len(specifiers) < 2 {
+			continue
+		}
+
+		d := Dependency{Name: name}
+		for specifier, projects := range specifiers {
+			sort.Strings(projects)
+			d.Usages = append(d.Usages, Usage{Specifier: specifier, Projects: projects})
+		}
+
+		// The most widely used specifier comes first
+		sort.Slice(d.Usages, func(i, j int) bool {
+			if len(d.Usages[i].Projects) != len(d.Usages[j].Projects) {
+				return len(d.Usages[i].Projects) > len(d.Usages[j].Projects)
+			}
+
+			return d.Usages[i].Specifier < d.Usages[j].Specifier
+		})
+
+		drifted = append(drifted, d)
+	}
+
+	sort.Slice(drifted, func(i, j int) bool {
+		return drifted[i].Name < drifted[j].Name
+	})
+
+	return drifted, nil
+}
+
+func appendUnique(projects []string, project string) []string {
+	for _, p := range projects {
+		if p == project {
+			return projects
+		}
+	}
+
+	return append(projects, project)
+}
+
+func WriteText(w io.Writer, drifted []Dependency) error {
+	for _, d := range drifted {
+		if _, err := fmt.Fprintln(w, color.GreenString(d.Name)); err != nil {
+			return err
+		}
+
+		for _, u := range d.Usages {
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", u.Specifier, strings.Join(u.Projects, " ")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func WriteJSON(w io.Writer, drifted []Dependency) error {
+	if drifted == nil {
+		drifted = []Dependency{}
+	}
+
+	b, err := json.MarshalIndent(drifted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
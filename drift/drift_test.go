@@ -0,0 +1,49 @@
+package drift_test
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/LGUG2Z/story/drift"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Drift", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+
+		// Given projects which use different versions of lodash and the same version of react
+		for project, contents := range map[string]string{
+			"api":   `{"name": "api", "dependencies": {"lodash": "^4.17.0", "react": "^16.0.0", "lib": "git+ssh://git@github.com:test-org/lib.git"}}`,
+			"app":   `{"name": "app", "dependencies": {"react": "^16.0.0"}, "devDependencies": {"lodash": "^4.17.0"}}`,
+			"lib":   `{"name": "lib", "dependencies": {"lodash": "^3.10.0"}}`,
+			"admin": `{"name": "admin", "dependencies": {"lib": "git+ssh://git@github.com:test-org/lib.git#story"}}`,
+		} {
+			Expect(fs.MkdirAll(project, os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, project+"/package.json", []byte(contents), os.FileMode(0666))).To(Succeed())
+		}
+	})
+
+	It("Should report external dependencies declared with more than one specifier", func() {
+		drifted, err := drift.Find(fs, []string{"admin", "api", "app", "lib"}, map[string]bool{"lib": true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(drifted).To(Equal([]drift.Dependency{{
+			Name: "lodash",
+			Usages: []drift.Usage{
+				{Specifier: "^4.17.0", Projects: []string{"api", "app"}},
+				{Specifier: "^3.10.0", Projects: []string{"lib"}},
+			},
+		}}))
+	})
+
+	It("Should write the report as JSON", func() {
+		var b bytes.Buffer
+		Expect(drift.WriteJSON(&b, nil)).To(Succeed())
+		Expect(b.String()).To(Equal("[]\n"))
+	})
+})
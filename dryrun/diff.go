@@ -0,0 +1,166 @@
+package dryrun
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change
+const context = 3
+
+type edit struct {
+	kind byte
+	line string
+}
+
+func lines(b []byte) []string {
+	var split []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			i = len(b) - 1
+		}
+
+		split = append(split, string(b[:i+1]))
+		b = b[i+1:]
+	}
+
+	return split
+}
+
+// edits returns the shortest edit script from a to b using the longest common subsequence
+func edits(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var script []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			script = append(script, edit{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			script = append(script, edit{'+', b[j]})
+			j++
+		default:
+			script = append(script, edit{'-', a[i]})
+			i++
+		}
+	}
+
+	return script
+}
+
+// Unified returns a unified diff of a file, or an empty string if it has not changed
+func Unified(name string, before, after []byte) string {
+	script := edits(lines(before), lines(after))
+
+	var hunks []string
+	for start := 0; start < len(script); {
+		// Find the next change
+		for start < len(script) && script[start].kind == ' ' {
+			start++
+		}
+
+		if start == len(script) {
+			break
+		}
+
+		// Extend the hunk until there are more unchanged lines than fit in the context
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+
+		end := start
+		for unchanged := 0; end < len(script) && unchanged <= 2*context; end++ {
+			if script[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+
+		for end > start && script[end-1].kind == ' ' && trailing(script[start:end]) > context {
+			end--
+		}
+
+		hunks = append(hunks, hunk(script, first, end))
+		start = end
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n%s", name, name, strings.Join(hunks, ""))
+}
+
+func trailing(script []edit) int {
+	n := 0
+	for i := len(script) - 1; i >= 0 && script[i].kind == ' '; i-- {
+		n++
+	}
+
+	return n
+}
+
+func hunk(script []edit, first, end int) string {
+	// Line numbers of the hunk in the old and new files are one-based
+	oldStart, newStart := 1, 1
+	for _, e := range script[:first] {
+		if e.kind != '+' {
+			oldStart++
+		}
+
+		if e.kind != '-' {
+			newStart++
+		}
+	}
+
+	var body strings.Builder
+	oldLines, newLines := 0, 0
+	for _, e := range script[first:end] {
+		if e.kind != '+' {
+			oldLines++
+		}
+
+		if e.kind != '-' {
+			newLines++
+		}
+
+		line := e.line
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n\\ No newline at end of file\n"
+		}
+
+		body.WriteString(fmt.Sprintf("%c%s", e.kind, line))
+	}
+
+	if oldLines == 0 {
+		oldStart--
+	}
+
+	if newLines == 0 {
+		newStart--
+	}
+
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", oldStart, oldLines, newStart, newLines, body.String())
+}
@@ -0,0 +1,120 @@
+package dryrun
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Operation is a change which would have been made to a project
+type Operation struct {
+	Project     string `json:"project" yaml:"project"`
+	Description string `json:"operation" yaml:"operation"`
+}
+
+var (
+	mutex      sync.Mutex
+	enabled    bool
+	operations []Operation
+)
+
+// Enable turns recording on or off and forgets any recorded operations
+func Enable(on bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	enabled = on
+	operations = nil
+}
+
+// Enabled reports whether operations should be recorded instead of performed
+func Enabled() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return enabled
+}
+
+// Record records an operation on a project, returning a description to use as its output
+func Record(project, command string, args ...string) string {
+	description := strings.Join(append([]string{command}, quote(args)...), " ")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	operations = append(operations, Operation{Project: project, Description: description})
+
+	return fmt.Sprintf("would run: %s", description)
+}
+
+func quote(args []string) []string {
+	var quoted []string
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return quoted
+}
+
+// Operations returns the recorded operations grouped by project, in the order they were
+// recorded within each project
+func Operations() []Operation {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	sorted := make([]Operation, len(operations))
+	copy(sorted, operations)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Project < sorted[j].Project
+	})
+
+	return sorted
+}
+
+// requestProject returns the repository of a GitHub API request, or the host of any other request
+func requestProject(u *url.URL) string {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 3 && parts[0] == "repos" {
+		return parts[2]
+	}
+
+	return u.Host
+}
+
+// Transport records requests which would change anything instead of sending them, and
+// sends other requests with the base transport
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		base := t.Base
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		return base.RoundTrip(r)
+	}
+
+	output := Record(requestProject(r.URL), r.Method, r.URL.String())
+
+	body := fmt.Sprintf(`{"number": 0, "html_url": %q}`, output)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		Request:    r,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package dryrun_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dryrun", func() {
+	BeforeEach(func() {
+		dryrun.Enable(true)
+	})
+
+	AfterEach(func() {
+		dryrun.Enable(false)
+	})
+
+	It("Should record operations grouped by project in the order they were recorded", func() {
+		Expect(dryrun.Record("web", "git", "checkout", "-b", "sso-login")).To(Equal("would run: git checkout -b sso-login"))
+		dryrun.Record("api", "git", "commit", "--message", "add sso")
+		dryrun.Record("web", "git", "push", "-u", "origin", "sso-login")
+
+		Expect(dryrun.Operations()).To(Equal([]dryrun.Operation{
+			{Project: "api", Description: `git commit --message "add sso"`},
+			{Project: "web", Description: "git checkout -b sso-login"},
+			{Project: "web", Description: "git push -u origin sso-login"},
+		}))
+	})
+
+	It("Should forget recorded operations when it is enabled again", func() {
+		dryrun.Record("web", "git", "add", "package.json")
+		dryrun.Enable(true)
+
+		Expect(dryrun.Operations()).To(BeEmpty())
+	})
+
+	It("Should send reads and record writes made over HTTP", func() {
+		var methods []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &dryrun.Transport{}}
+
+		response, err := client.Get(server.URL + "/repos/test-org/api/pulls")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		response, err = client.Post(server.URL+"/repos/test-org/api/pulls", "application/json", strings.NewReader("{}"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("would run: POST"))
+
+		Expect(methods).To(Equal([]string{http.MethodGet}))
+		Expect(dryrun.Operations()).To(Equal([]dryrun.Operation{
+			{Project: "api", Description: "POST " + server.URL + "/repos/test-org/api/pulls"},
+		}))
+	})
+
+	It("Should describe changed lines with a unified diff", func() {
+		before := "{\n  \"name\": \"web\",\n  \"dependencies\": {\n    \"lib-1\": \"^1.0.0\"\n  }\n}\n"
+		after := "{\n  \"name\": \"web\",\n  \"dependencies\": {\n    \"lib-1\": \"1.1.0-sso-login.1234567\"\n  }\n}\n"
+
+		Expect(dryrun.Unified("web/package.json", []byte(before), []byte(after))).To(Equal(`--- a/web/package.json
++++ b/web/package.json
+@@ -1,6 +1,6 @@
+ {
+   "name": "web",
+   "dependencies": {
+-    "lib-1": "^1.0.0"
++    "lib-1": "1.1.0-sso-login.1234567"
+   }
+ }
+`))
+	})
+
+	It("Should describe new files as every line being added", func() {
+		Expect(dryrun.Unified(".meta", nil, []byte("a\nb\n"))).To(Equal("--- a/.meta\n+++ b/.meta\n@@ -0,0 +1,2 @@\n+a\n+b\n"))
+	})
+
+	It("Should keep distant changes in separate hunks", func() {
+		var before, after []string
+		for i := 1; i <= 20; i++ {
+			before = append(before, fmt.Sprintf("line %d", i))
+			after = append(after, fmt.Sprintf("line %d", i))
+		}
+
+		after[1] = "first"
+		after[18] = "second"
+
+		diff := dryrun.Unified("file", []byte(strings.Join(before, "\n")+"\n"), []byte(strings.Join(after, "\n")+"\n"))
+
+		Expect(strings.Count(diff, "@@ -")).To(Equal(2))
+		Expect(diff).To(ContainSubstring("@@ -1,5 +1,5 @@\n line 1\n-line 2\n+first\n line 3\n"))
+		Expect(diff).To(ContainSubstring("@@ -16,5 +16,5 @@\n line 16\n line 17\n line 18\n-line 19\n+second\n line 20\n"))
+	})
+
+	It("Should not describe unchanged files", func() {
+		Expect(dryrun.Unified("file", []byte("a\n"), []byte("a\n"))).To(BeEmpty())
+	})
+})
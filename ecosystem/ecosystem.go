@@ -0,0 +1,141 @@
+package ecosystem
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+)
+
+// Dependency is a dependency declared in the manifest of a project
+type Dependency struct {
+	Name      string `json:"name"`
+	Section   string `json:"section"`
+	Specifier string `json:"specifier"`
+}
+
+// Adapter reads and rewrites the dependency manifest of a project for a
+// particular language ecosystem
+type Adapter interface {
+	// Ecosystem is the name of the ecosystem handled by the adapter
+	Ecosystem() string
+	// Files are the manifest files of the project, relative to the project
+	Files() []string
+	Load(fs afero.Fs, project string) error
+	Write(fs afero.Fs, project string) error
+	// Name is the name other projects use when depending on the project
+	Name() string
+	// Dependencies are every dependency declared by the project; dependencies on
+	// other metarepo projects are identified by matching against their names
+	Dependencies() []Dependency
+	SetDependencyBranchesToStory(story string, projects ...string)
+	SetDependencyBranchesToCommitHashes(story *manifest.Story, projects ...string) error
+	ResetDependencyBranchesToTrunk(story string)
+	ResetDependencyBranches(toReset, story string)
+}
+
+// RefPinner is implemented by adapters which reference dependencies by git refs, which
+// allows them to be pinned to tags instead of commit hashes
+type RefPinner interface {
+	SetDependencyBranchesToRefs(refs map[string]string, projects ...string)
+}
+
+// Restorer is implemented by adapters which rewrite the versions of dependencies in place,
+// so that the versions they replace can be recorded and put back when resetting to trunk
+type Restorer interface {
+	// Originals are the specifiers of dependencies on metarepo projects from before they
+	// were first rewritten since the manifest was loaded, by project
+	Originals() map[string]string
+	// Restore sets dependencies on metarepo projects back to their original specifiers
+	Restore(originals map[string]string)
+}
+
+var adapters = []func() Adapter{
+	func() Adapter { return &Node{} },
+	func() Adapter { return &Go{} },
+	func() Adapter { return &Python{} },
+	func() Adapter { return &Maven{} },
+}
+
+// Detect returns an unloaded adapter for the first ecosystem with a manifest file in the project
+func Detect(fs afero.Fs, project string) (Adapter, error) {
+	for _, adapter := range adapters {
+		a := adapter()
+		for _, file := range a.Files() {
+			exists, err := afero.Exists(fs, fmt.Sprintf("%s/%s", project, file))
+			if err != nil {
+				return nil, err
+			}
+
+			if exists {
+				return a, nil
+			}
+		}
+	}
+
+	return nil, ErrNoManifest(project)
+}
+
+// Load detects the ecosystem of a project and loads its manifest
+func Load(fs afero.Fs, project string) (Adapter, error) {
+	a, err := Detect(fs, project)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.Load(fs, project); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func ErrNoManifest(project string) error {
+	return fmt.Errorf("%s does not have a supported dependency manifest", project)
+}
+
+// ManifestFiles returns the manifest files of an adapter which exist in a project
+func ManifestFiles(fs afero.Fs, project string, a Adapter) ([]string, error) {
+	var files []string
+	for _, file := range a.Files() {
+		exists, err := afero.Exists(fs, fmt.Sprintf("%s/%s", project, file))
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// IsManifest reports whether a file in the root of a project is a manifest file of any supported ecosystem
+func IsManifest(file string) bool {
+	for _, adapter := range adapters {
+		for _, f := range adapter().Files() {
+			if f == file {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// declaredName loads a sibling of a loaded project in the metarepo with an adapter for the
+// same ecosystem, and returns the name it declares, which is how the project depends on it
+func declaredName(fs afero.Fs, loaded, sibling string, a Adapter) (string, bool) {
+	if fs == nil {
+		return "", false
+	}
+
+	if err := a.Load(fs, filepath.Join(filepath.Dir(loaded), sibling)); err != nil {
+		return "", false
+	}
+
+	name := a.Name()
+	return name, name != ""
+}
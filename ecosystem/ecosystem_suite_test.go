@@ -0,0 +1,13 @@
+package ecosystem_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEcosystem(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ecosystem Suite")
+}
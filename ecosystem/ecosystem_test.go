@@ -0,0 +1,262 @@
+package ecosystem_test
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var fs afero.Fs
+
+func write(filename, contents string) {
+	Expect(afero.WriteFile(fs, filename, []byte(contents), os.FileMode(0666))).To(Succeed())
+}
+
+func read(filename string) string {
+	b, err := afero.ReadFile(fs, filename)
+	Expect(err).NotTo(HaveOccurred())
+	return string(b)
+}
+
+var goMod = `module github.com/test-org/app
+
+go 1.12
+
+require github.com/test-org/lib-1 v1.2.0
+
+require (
+	github.com/pkg/errors v0.8.1
+	github.com/test-org/lib-2/v2 v2.0.1 // indirect
+)
+`
+
+var requirementsTxt = `requests==2.22.0
+lib-1 @ git+ssh://git@github.com/test-org/lib-1.git
+git+https://github.com/test-org/lib-2.git@develop#egg=lib-2
+`
+
+var pyprojectToml = `[project]
+name = "app"
+dependencies = [
+  "lib-1 @ git+ssh://git@github.com/test-org/lib-1.git",
+  "click>=7",
+]
+
+[tool.black]
+line-length = 100
+`
+
+var pomXML = `<project>
+  <parent>
+    <groupId>org.test</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>org.test</groupId>
+      <artifactId>lib-1</artifactId>
+      <version>1.4.0-SNAPSHOT</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.12</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+var _ = Describe("Ecosystem", func() {
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+		Expect(fs.MkdirAll("app", os.FileMode(0700))).To(Succeed())
+	})
+
+	Describe("Detecting the ecosystem of a project", func() {
+		It("Should prefer package.json over other manifests", func() {
+			Expect(afero.WriteFile(fs, "app/package.json", []byte(`{"name": "app"}`), os.FileMode(0666))).To(Succeed())
+			Expect(afero.WriteFile(fs, "app/requirements.txt", []byte(requirementsTxt), os.FileMode(0666))).To(Succeed())
+
+			a, err := ecosystem.Detect(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Ecosystem()).To(Equal("node"))
+		})
+
+		It("Should return an error for a project without a supported manifest", func() {
+			_, err := ecosystem.Detect(fs, "app")
+			Expect(err).To(MatchError(ecosystem.ErrNoManifest("app")))
+		})
+
+		It("Should recognise manifest files of every ecosystem", func() {
+			Expect(ecosystem.IsManifest("go.mod")).To(BeTrue())
+			Expect(ecosystem.IsManifest("pom.xml")).To(BeTrue())
+			Expect(ecosystem.IsManifest("README.md")).To(BeFalse())
+		})
+	})
+
+	Describe("Go", func() {
+		BeforeEach(func() {
+			write("app/go.mod", goMod)
+
+			// The libraries are checked out next to the app, along with a project whose
+			// directory has the same name as a third-party module
+			for project, module := range map[string]string{"lib-1": "github.com/test-org/lib-1", "lib-2": "github.com/test-org/lib-2/v2", "errors": "github.com/test-org/errors"} {
+				Expect(fs.MkdirAll(project, os.FileMode(0700))).To(Succeed())
+				write(project+"/go.mod", "module "+module+"\n")
+			}
+		})
+
+		It("Should read the module path and requirements", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Name()).To(Equal("github.com/test-org/app"))
+			Expect(a.Dependencies()).To(Equal([]ecosystem.Dependency{
+				{Name: "github.com/test-org/lib-1", Section: "require", Specifier: "v1.2.0"},
+				{Name: "github.com/pkg/errors", Section: "require", Specifier: "v0.8.1"},
+				{Name: "github.com/test-org/lib-2/v2", Section: "require", Specifier: "v2.0.1"},
+			}))
+		})
+
+		It("Should replace story projects with their checkouts and remove the replacements again", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+
+			a.SetDependencyBranchesToStory("test-story", "lib-1", "lib-2", "errors")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/go.mod")).NotTo(ContainSubstring("github.com/pkg/errors =>"))
+			Expect(read("app/go.mod")).To(ContainSubstring("replace github.com/test-org/lib-1 => ../lib-1 // story:test-story\n"))
+			Expect(read("app/go.mod")).To(ContainSubstring("replace github.com/test-org/lib-2/v2 => ../lib-2 // story:test-story\n"))
+			Expect(a.(ecosystem.Restorer).Originals()).To(Equal(map[string]string{"lib-1": "v1.2.0", "lib-2": "v2.0.1"}))
+
+			a.ResetDependencyBranches("lib-2", "test-story")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/go.mod")).NotTo(ContainSubstring("../lib-2"))
+
+			a.ResetDependencyBranchesToTrunk("test-story")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/go.mod")).To(Equal(goMod))
+		})
+
+		It("Should make pseudo-versions which sort after the required version", func() {
+			committed := time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC)
+			hash := "0123456789abcdef"
+
+			Expect(ecosystem.PseudoVersion("v1.4.2", 1, committed, hash)).To(Equal("v1.4.3-0.20190102150405-0123456789ab"))
+			Expect(ecosystem.PseudoVersion("v2.0.0-rc.1", 2, committed, hash)).To(Equal("v2.0.0-rc.1.0.20190102150405-0123456789ab"))
+			Expect(ecosystem.PseudoVersion("v3.1.0+incompatible", 0, committed, hash)).To(Equal("v3.1.1-0.20190102150405-0123456789ab+incompatible"))
+			Expect(ecosystem.PseudoVersion("v1.4.3-0.20181201000000-aaaaaaaaaaaa", 1, committed, hash)).To(Equal("v1.4.3-0.20190102150405-0123456789ab"))
+			Expect(ecosystem.PseudoVersion("v0.0.0-20181201000000-aaaaaaaaaaaa", 0, committed, hash)).To(Equal("v0.0.0-20190102150405-0123456789ab"))
+			Expect(ecosystem.PseudoVersion("latest", 2, committed, hash)).To(Equal("v2.0.0-20190102150405-0123456789ab"))
+		})
+
+		It("Should restore the versions required before the story", func() {
+			// Given lib-1 is pinned to a pseudo-version
+			write("app/go.mod", strings.Replace(goMod, "lib-1 v1.2.0", "lib-1 v0.0.0-20190102150405-0123456789ab", 1))
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+
+			// When the version recorded when it was first rewritten is restored
+			a.ResetDependencyBranchesToTrunk("test-story")
+			a.(ecosystem.Restorer).Restore(map[string]string{"lib-1": "v1.2.0"})
+			Expect(a.Write(fs, "app")).To(Succeed())
+
+			// Then the original requirement is back
+			Expect(read("app/go.mod")).To(Equal(goMod))
+		})
+	})
+
+	Describe("Python", func() {
+		BeforeEach(func() {
+			Expect(afero.WriteFile(fs, "app/requirements.txt", []byte(requirementsTxt), os.FileMode(0666))).To(Succeed())
+			Expect(afero.WriteFile(fs, "app/pyproject.toml", []byte(pyprojectToml), os.FileMode(0666))).To(Succeed())
+		})
+
+		It("Should read the project name and requirements", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Name()).To(Equal("app"))
+			Expect(a.Dependencies()).To(Equal([]ecosystem.Dependency{
+				{Name: "requests", Section: "requirements.txt", Specifier: "==2.22.0"},
+				{Name: "lib-1", Section: "requirements.txt", Specifier: "git+ssh://git@github.com/test-org/lib-1.git"},
+				{Name: "lib-2", Section: "requirements.txt", Specifier: "git+https://github.com/test-org/lib-2.git@develop#egg=lib-2"},
+				{Name: "lib-1", Section: "pyproject.toml", Specifier: "git+ssh://git@github.com/test-org/lib-1.git"},
+				{Name: "click", Section: "pyproject.toml", Specifier: ">=7"},
+			}))
+		})
+
+		It("Should point git references at the story, then at commits, then back at trunk", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+
+			a.SetDependencyBranchesToStory("test-story", "lib-1", "lib-2")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/requirements.txt")).To(ContainSubstring("lib-1.git@test-story\n"))
+			Expect(read("app/requirements.txt")).To(ContainSubstring("lib-2.git@test-story#egg=lib-2"))
+			Expect(read("app/pyproject.toml")).To(ContainSubstring(`lib-1.git@test-story"`))
+
+			story := &manifest.Story{Name: "test-story", Hashes: map[string]string{"lib-1": "abc123"}}
+			Expect(a.SetDependencyBranchesToCommitHashes(story, "lib-1")).To(Succeed())
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/requirements.txt")).To(ContainSubstring("lib-1.git@abc123\n"))
+
+			a.ResetDependencyBranchesToTrunk("test-story")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/requirements.txt")).To(ContainSubstring("lib-2.git#egg=lib-2"))
+		})
+	})
+
+	Describe("Maven", func() {
+		BeforeEach(func() {
+			write("app/pom.xml", pomXML)
+
+			// The library is checked out next to the app, along with a project whose
+			// directory has the same name as a third-party artifact
+			for project, groupID := range map[string]string{"lib-1": "org.test", "junit": "org.test"} {
+				Expect(fs.MkdirAll(project, os.FileMode(0700))).To(Succeed())
+				write(project+"/pom.xml", "<project>\n  <groupId>"+groupID+"</groupId>\n  <artifactId>"+project+"</artifactId>\n</project>\n")
+			}
+		})
+
+		It("Should read the group and artifact ids and dependencies", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Name()).To(Equal("org.test:app"))
+			Expect(a.Dependencies()).To(Equal([]ecosystem.Dependency{
+				{Name: "org.test:lib-1", Section: "dependencies", Specifier: "1.4.0-SNAPSHOT"},
+				{Name: "junit:junit", Section: "dependencies (test)", Specifier: "4.12"},
+			}))
+		})
+
+		It("Should use story snapshots, then commit versions, then the original versions", func() {
+			a, err := ecosystem.Load(fs, "app")
+			Expect(err).NotTo(HaveOccurred())
+
+			a.SetDependencyBranchesToStory("feature/test-story", "lib-1", "junit")
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/pom.xml")).To(ContainSubstring("<version>1.4.0-feature-test-story-SNAPSHOT</version>"))
+			Expect(read("app/pom.xml")).To(ContainSubstring("<version>4.12</version>"))
+
+			story := &manifest.Story{Name: "feature/test-story", Hashes: map[string]string{"lib-1": "0123456789abcdef"}}
+			Expect(a.SetDependencyBranchesToCommitHashes(story, "lib-1")).To(Succeed())
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/pom.xml")).To(ContainSubstring("<version>1.4.0-0123456</version>"))
+
+			originals := a.(ecosystem.Restorer).Originals()
+			Expect(originals).To(Equal(map[string]string{"lib-1": "1.4.0-SNAPSHOT"}))
+
+			a.ResetDependencyBranchesToTrunk("feature/test-story")
+			a.(ecosystem.Restorer).Restore(originals)
+			Expect(a.Write(fs, "app")).To(Succeed())
+			Expect(read("app/pom.xml")).To(Equal(pomXML))
+		})
+	})
+})
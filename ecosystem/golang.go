@@ -0,0 +1,300 @@
+package ecosystem
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+)
+
+// Go manages go.mod files. Story branches are referenced with replace directives pointing
+// at the sibling checkout in the metarepo, which are marked with a comment so that they can
+// be removed again, and commits are referenced with pseudo-versions.
+type Go struct {
+	fs        afero.Fs
+	project   string
+	module    string
+	lines     []string
+	requires  []Dependency
+	originals map[string]string
+}
+
+var majorVersionSuffix = regexp.MustCompile(`/v([0-9]+)$`)
+
+var (
+	semanticVersion = regexp.MustCompile(`^v([0-9]+)\.([0-9]+)\.([0-9]+)(-[0-9A-Za-z.-]+)?(\+incompatible)?$`)
+	pseudoVersion   = regexp.MustCompile(`^(v[0-9]+\.(?:0\.0-|[0-9]+\.[0-9]+-(?:[0-9A-Za-z.-]*\.)?0\.))[0-9]{14}-[0-9a-f]{12}(\+incompatible)?$`)
+)
+
+// PseudoVersion returns the pseudo-version of a commit, based on the version a module is
+// currently required at so that it sorts after it as the go command expects. Modules which
+// are not required at a semantic version get a v0.0.0 pseudo-version for their major version.
+func PseudoVersion(current string, major int, committed time.Time, hash string) string {
+	revision := fmt.Sprintf("%s-%s", committed.UTC().Format("20060102150405"), hash[:12])
+
+	// Pseudo-versions from an earlier pin keep the release they were based on
+	if m := pseudoVersion.FindStringSubmatch(current); m != nil {
+		return m[1] + revision + m[2]
+	}
+
+	m := semanticVersion.FindStringSubmatch(current)
+	if m == nil {
+		return fmt.Sprintf("v%d.0.0-%s", major, revision)
+	}
+
+	// Prereleases are followed by their own pseudo-versions, and releases by those of
+	// the next patch version
+	if m[4] != "" {
+		return fmt.Sprintf("v%s.%s.%s%s.0.%s%s", m[1], m[2], m[3], m[4], revision, m[5])
+	}
+
+	patch, _ := strconv.Atoi(m[3])
+	return fmt.Sprintf("v%s.%s.%d-0.%s%s", m[1], m[2], patch+1, revision, m[5])
+}
+
+func (g *Go) Ecosystem() string {
+	return "go"
+}
+
+func (g *Go) Files() []string {
+	return []string{"go.mod"}
+}
+
+func (g *Go) Name() string {
+	return g.module
+}
+
+func (g *Go) Dependencies() []Dependency {
+	return g.requires
+}
+
+func (g *Go) Load(fs afero.Fs, project string) error {
+	b, err := afero.ReadFile(fs, fmt.Sprintf("%s/go.mod", project))
+	if err != nil {
+		return err
+	}
+
+	g.fs, g.project = fs, project
+	g.originals = make(map[string]string)
+	g.lines = strings.Split(string(b), "\n")
+	g.parse()
+
+	return nil
+}
+
+func (g *Go) parse() {
+	g.module = ""
+	g.requires = nil
+
+	for _, line := range g.lines {
+		fields := strings.Fields(stripGoComment(line))
+		if len(fields) >= 2 && fields[0] == "module" {
+			g.module = strings.Trim(fields[1], `"`)
+		}
+	}
+
+	for _, i := range g.requireLines() {
+		fields := strings.Fields(stripGoComment(g.lines[i]))
+		if fields[0] == "require" {
+			fields = fields[1:]
+		}
+
+		g.requires = append(g.requires, Dependency{Name: fields[0], Section: "require", Specifier: fields[1]})
+	}
+}
+
+// requireLines returns the indexes of lines which require a module, either
+// as a single require directive or within a require block
+func (g *Go) requireLines() []int {
+	var indexes []int
+
+	block := ""
+	for i, line := range g.lines {
+		fields := strings.Fields(stripGoComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+		case block == "require" && len(fields) >= 2:
+			indexes = append(indexes, i)
+		case block == "":
+			if len(fields) >= 2 && (fields[0] == "require" || fields[0] == "replace") && fields[1] == "(" {
+				block = fields[0]
+			} else if len(fields) >= 3 && fields[0] == "require" {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+
+	return indexes
+}
+
+func stripGoComment(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		return line[:i]
+	}
+
+	return line
+}
+
+func (g *Go) Write(fs afero.Fs, project string) error {
+	filename := fmt.Sprintf("%s/go.mod", project)
+	return afero.WriteFile(fs, filename, []byte(strings.Join(g.lines, "\n")), os.FileMode(0666))
+}
+
+// moduleFor returns the required module provided by a metarepo project, which is the
+// module path declared in the go.mod file of its checkout
+func (g *Go) moduleFor(project string) (Dependency, bool) {
+	module, ok := declaredName(g.fs, g.project, project, &Go{})
+	if !ok {
+		return Dependency{}, false
+	}
+
+	for _, r := range g.requires {
+		if r.Name == module {
+			return r, true
+		}
+	}
+
+	return Dependency{}, false
+}
+
+// keep records the version a project was required at before it is first rewritten
+func (g *Go) keep(project string, r Dependency) {
+	if _, kept := g.originals[project]; !kept {
+		g.originals[project] = r.Specifier
+	}
+}
+
+// setRequire changes the version a module is required at
+func (g *Go) setRequire(r Dependency, version string) {
+	for _, i := range g.requireLines() {
+		g.lines[i] = strings.Replace(g.lines[i], fmt.Sprintf("%s %s", r.Name, r.Specifier), fmt.Sprintf("%s %s", r.Name, version), 1)
+	}
+}
+
+func (g *Go) Originals() map[string]string {
+	return g.originals
+}
+
+func (g *Go) Restore(originals map[string]string) {
+	for project, version := range originals {
+		if r, exists := g.moduleFor(project); exists {
+			g.setRequire(r, version)
+		}
+	}
+
+	g.parse()
+}
+
+func storyMarker(story string) string {
+	return fmt.Sprintf("// story:%s", story)
+}
+
+func (g *Go) removeLines(matches func(line string) bool) {
+	var lines []string
+	for _, line := range g.lines {
+		if !matches(line) {
+			lines = append(lines, line)
+		}
+	}
+
+	g.lines = lines
+}
+
+func (g *Go) removeStoryReplace(module string) {
+	g.removeLines(func(line string) bool {
+		fields := strings.Fields(line)
+		return len(fields) >= 2 && fields[0] == "replace" && fields[1] == module && strings.Contains(line, "// story:")
+	})
+}
+
+func (g *Go) SetDependencyBranchesToStory(story string, projects ...string) {
+	for _, project := range projects {
+		r, exists := g.moduleFor(project)
+		if !exists {
+			continue
+		}
+
+		g.keep(project, r)
+		g.removeStoryReplace(r.Name)
+
+		replace := fmt.Sprintf("replace %s => ../%s %s", r.Name, project, storyMarker(story))
+
+		// Keep a trailing newline at the end of the file
+		if len(g.lines) > 0 && g.lines[len(g.lines)-1] == "" {
+			g.lines = append(g.lines[:len(g.lines)-1], replace, "")
+		} else {
+			g.lines = append(g.lines, replace)
+		}
+	}
+}
+
+func (g *Go) SetDependencyBranchesToCommitHashes(story *manifest.Story, projects ...string) error {
+	for _, project := range projects {
+		r, exists := g.moduleFor(project)
+		if !exists {
+			continue
+		}
+
+		hash := story.Hashes[project]
+		if len(hash) < 12 {
+			continue
+		}
+
+		committed, err := git.CommitTime(project, hash)
+		if err != nil {
+			return err
+		}
+
+		major := 0
+		if m := majorVersionSuffix.FindStringSubmatch(r.Name); m != nil {
+			major, _ = strconv.Atoi(m[1])
+		}
+
+		g.keep(project, r)
+
+		// A later pin is based on the version required before the story, not an earlier pin
+		current := r.Specifier
+		if original, ok := story.Originals[g.project][project]; ok {
+			current = original
+		}
+
+		g.setRequire(r, PseudoVersion(current, major, committed, hash))
+
+		g.removeStoryReplace(r.Name)
+	}
+
+	g.parse()
+
+	return nil
+}
+
+func (g *Go) ResetDependencyBranchesToTrunk(story string) {
+	marker := storyMarker(story)
+	g.removeLines(func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "replace ") && strings.HasSuffix(strings.TrimSpace(line), marker)
+	})
+}
+
+func (g *Go) ResetDependencyBranches(toReset, story string) {
+	r, exists := g.moduleFor(toReset)
+	if !exists {
+		return
+	}
+
+	marker := storyMarker(story)
+	g.removeLines(func(line string) bool {
+		fields := strings.Fields(line)
+		return len(fields) >= 2 && fields[0] == "replace" && fields[1] == r.Name && strings.HasSuffix(strings.TrimSpace(line), marker)
+	})
+}
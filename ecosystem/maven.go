@@ -0,0 +1,223 @@
+package ecosystem
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+)
+
+// Maven manages the versions of dependencies in pom.xml files. Story branches are
+// referenced as <version>-<story>-SNAPSHOT and commits as <version>-<short hash>,
+// which are expected to be published by CI builds of the story branches.
+type Maven struct {
+	fs        afero.Fs
+	project   string
+	contents  string
+	originals map[string]string
+}
+
+var (
+	mavenDependency = regexp.MustCompile(`(?s)<dependency>.*?</dependency>`)
+	mavenGroupID    = regexp.MustCompile(`<groupId>\s*([^<\s]+)\s*</groupId>`)
+	mavenArtifactID = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	mavenParent     = regexp.MustCompile(`(?s)<parent>.*?</parent>`)
+	mavenVersion    = regexp.MustCompile(`<version>\s*([^<\s]+)\s*</version>`)
+	mavenScope      = regexp.MustCompile(`<scope>\s*([^<\s]+)\s*</scope>`)
+	mavenNested     = regexp.MustCompile(`(?s)<(parent|dependencies|dependencyManagement|build|profiles)>.*?</(parent|dependencies|dependencyManagement|build|profiles)>`)
+)
+
+func (m *Maven) Ecosystem() string {
+	return "maven"
+}
+
+func (m *Maven) Files() []string {
+	return []string{"pom.xml"}
+}
+
+func (m *Maven) Load(fs afero.Fs, project string) error {
+	b, err := afero.ReadFile(fs, fmt.Sprintf("%s/pom.xml", project))
+	if err != nil {
+		return err
+	}
+
+	m.fs, m.project = fs, project
+	m.originals = make(map[string]string)
+	m.contents = string(b)
+	return nil
+}
+
+func (m *Maven) Write(fs afero.Fs, project string) error {
+	filename := fmt.Sprintf("%s/pom.xml", project)
+	return afero.WriteFile(fs, filename, []byte(m.contents), os.FileMode(0666))
+}
+
+// Name is the groupId and artifactId of the project, with the groupId inherited from
+// the parent if the project does not declare its own
+func (m *Maven) Name() string {
+	project := mavenNested.ReplaceAllString(m.contents, "")
+
+	artifactID := mavenArtifactID.FindStringSubmatch(project)
+	if artifactID == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%s", m.groupID(), artifactID[1])
+}
+
+func (m *Maven) groupID() string {
+	if match := mavenGroupID.FindStringSubmatch(mavenNested.ReplaceAllString(m.contents, "")); match != nil {
+		return match[1]
+	}
+
+	if match := mavenGroupID.FindStringSubmatch(mavenParent.FindString(m.contents)); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// coordinates returns the groupId and artifactId of a dependency block
+func (m *Maven) coordinates(block string) (string, bool) {
+	artifactID := mavenArtifactID.FindStringSubmatch(block)
+	if artifactID == nil {
+		return "", false
+	}
+
+	groupID := m.groupID()
+	if match := mavenGroupID.FindStringSubmatch(block); match != nil && match[1] != "${project.groupId}" {
+		groupID = match[1]
+	}
+
+	return fmt.Sprintf("%s:%s", groupID, artifactID[1]), true
+}
+
+func (m *Maven) Dependencies() []Dependency {
+	var dependencies []Dependency
+	for _, block := range mavenDependency.FindAllString(m.contents, -1) {
+		name, ok := m.coordinates(block)
+		if !ok {
+			continue
+		}
+
+		d := Dependency{Name: name, Section: "dependencies"}
+		if version := mavenVersion.FindStringSubmatch(block); version != nil {
+			d.Specifier = version[1]
+		}
+
+		if scope := mavenScope.FindStringSubmatch(block); scope != nil {
+			d.Section = fmt.Sprintf("dependencies (%s)", scope[1])
+		}
+
+		dependencies = append(dependencies, d)
+	}
+
+	return dependencies
+}
+
+// setVersion rewrites the version of every dependency on a project, which is identified
+// by the groupId and artifactId declared in the pom.xml file of its checkout
+func (m *Maven) setVersion(project string, version func(current string) string) {
+	name, ok := declaredName(m.fs, m.project, project, &Maven{})
+	if !ok {
+		return
+	}
+
+	m.setVersions(func(dependency string) bool { return dependency == name }, version)
+}
+
+// setVersions rewrites the version of every dependency matching a groupId and artifactId
+func (m *Maven) setVersions(matches func(dependency string) bool, version func(current string) string) {
+	m.contents = mavenDependency.ReplaceAllStringFunc(m.contents, func(block string) string {
+		if name, ok := m.coordinates(block); !ok || !matches(name) {
+			return block
+		}
+
+		return mavenVersion.ReplaceAllStringFunc(block, func(v string) string {
+			current := mavenVersion.FindStringSubmatch(v)[1]
+
+			// Versions set from properties are left alone
+			if strings.HasPrefix(current, "${") {
+				return v
+			}
+
+			return strings.Replace(v, current, version(current), 1)
+		})
+	})
+}
+
+func storySnapshot(story string) string {
+	return fmt.Sprintf("-%s-SNAPSHOT", strings.ReplaceAll(story, "/", "-"))
+}
+
+func baseVersion(version, story string) string {
+	version = strings.TrimSuffix(version, storySnapshot(story))
+	return strings.TrimSuffix(version, "-SNAPSHOT")
+}
+
+// keep records the version of a dependency on a project before it is first rewritten
+func (m *Maven) keep(project, version string) {
+	if _, kept := m.originals[project]; !kept {
+		m.originals[project] = version
+	}
+}
+
+func (m *Maven) Originals() map[string]string {
+	return m.originals
+}
+
+func (m *Maven) Restore(originals map[string]string) {
+	for project, version := range originals {
+		m.setVersion(project, func(string) string {
+			return version
+		})
+	}
+}
+
+func (m *Maven) SetDependencyBranchesToStory(story string, projects ...string) {
+	for _, project := range projects {
+		m.setVersion(project, func(current string) string {
+			m.keep(project, current)
+			return baseVersion(current, story) + storySnapshot(story)
+		})
+	}
+}
+
+func (m *Maven) SetDependencyBranchesToCommitHashes(story *manifest.Story, projects ...string) error {
+	for _, project := range projects {
+		hash := story.Hashes[project]
+		if len(hash) < 7 {
+			continue
+		}
+
+		m.setVersion(project, func(current string) string {
+			m.keep(project, current)
+			return fmt.Sprintf("%s-%s", baseVersion(current, story.Name), hash[:7])
+		})
+	}
+
+	return nil
+}
+
+func (m *Maven) resetVersion(current, story string) string {
+	if strings.HasSuffix(current, storySnapshot(story)) {
+		return baseVersion(current, story) + "-SNAPSHOT"
+	}
+
+	return current
+}
+
+func (m *Maven) ResetDependencyBranchesToTrunk(story string) {
+	m.setVersions(func(string) bool { return true }, func(current string) string {
+		return m.resetVersion(current, story)
+	})
+}
+
+func (m *Maven) ResetDependencyBranches(toReset, story string) {
+	m.setVersion(toReset, func(current string) string {
+		return m.resetVersion(current, story)
+	})
+}
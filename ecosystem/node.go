@@ -0,0 +1,79 @@
+package ecosystem
+
+import (
+	"sort"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/LGUG2Z/story/node"
+	"github.com/spf13/afero"
+)
+
+type Node struct {
+	node.PackageJSON
+}
+
+func (n *Node) Ecosystem() string {
+	return "node"
+}
+
+func (n *Node) Files() []string {
+	return []string{"package.json"}
+}
+
+func (n *Node) Name() string {
+	return n.PackageJSON.Name
+}
+
+func (n *Node) Dependencies() []Dependency {
+	var dependencies []Dependency
+	for _, section := range []struct {
+		name string
+		deps map[string]string
+	}{{"dependencies", n.PackageJSON.Dependencies}, {"devDependencies", n.PackageJSON.DevDependencies}} {
+		var names []string
+		for name := range section.deps {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			dependencies = append(dependencies, Dependency{Name: name, Section: section.name, Specifier: section.deps[name]})
+		}
+	}
+
+	return dependencies
+}
+
+func (n *Node) Load(fs afero.Fs, project string) error {
+	return n.PackageJSON.Load(fs, project)
+}
+
+func (n *Node) Write(fs afero.Fs, project string) error {
+	return n.PackageJSON.Write(fs, project)
+}
+
+func (n *Node) SetDependencyBranchesToStory(story string, projects ...string) {
+	n.SetPrivateDependencyBranchesToStory(story, projects...)
+}
+
+func (n *Node) SetDependencyBranchesToCommitHashes(story *manifest.Story, projects ...string) error {
+	n.SetPrivateDependencyBranchesToCommitHashes(story, projects...)
+	return nil
+}
+
+func (n *Node) SetDependencyBranchesToRefs(refs map[string]string, projects ...string) {
+	for _, project := range projects {
+		if ref, exists := refs[project]; exists {
+			n.SetPrivateDependencyBranchesToStory(ref, project)
+		}
+	}
+}
+
+func (n *Node) ResetDependencyBranchesToTrunk(story string) {
+	n.ResetPrivateDependencyBranchesToMaster(story)
+}
+
+func (n *Node) ResetDependencyBranches(toReset, story string) {
+	n.ResetPrivateDependencyBranches(toReset, story)
+}
@@ -0,0 +1,220 @@
+package ecosystem
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/LGUG2Z/story/manifest"
+	"github.com/spf13/afero"
+)
+
+// Python manages PEP 508 git references such as "lib @ git+ssh://git@github.com/org/lib.git@ref"
+// in requirements.txt files and in the dependencies of pyproject.toml files
+type Python struct {
+	name     string
+	contents map[string]string
+}
+
+var (
+	pythonName        = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	pythonEgg         = regexp.MustCompile(`#egg=([A-Za-z0-9._-]+)`)
+	pythonProjectName = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+	pythonQuoted      = regexp.MustCompile(`"([^"]*)"`)
+)
+
+func (p *Python) Ecosystem() string {
+	return "python"
+}
+
+func (p *Python) Files() []string {
+	return []string{"pyproject.toml", "requirements.txt"}
+}
+
+func (p *Python) Name() string {
+	return p.name
+}
+
+func (p *Python) Load(fs afero.Fs, project string) error {
+	p.contents = make(map[string]string)
+	for _, file := range p.Files() {
+		filename := fmt.Sprintf("%s/%s", project, file)
+		exists, err := afero.Exists(fs, filename)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		b, err := afero.ReadFile(fs, filename)
+		if err != nil {
+			return err
+		}
+
+		p.contents[file] = string(b)
+	}
+
+	p.name = ""
+	if m := pythonProjectName.FindStringSubmatch(pyprojectSection(p.contents["pyproject.toml"], "project")); m != nil {
+		p.name = m[1]
+	}
+
+	return nil
+}
+
+func (p *Python) Write(fs afero.Fs, project string) error {
+	for file, contents := range p.contents {
+		filename := fmt.Sprintf("%s/%s", project, file)
+		if err := afero.WriteFile(fs, filename, []byte(contents), os.FileMode(0666)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pyprojectSection returns the contents of a table in a pyproject.toml file
+func pyprojectSection(contents, table string) string {
+	var section []string
+	in := false
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			in = trimmed == fmt.Sprintf("[%s]", table)
+			continue
+		}
+
+		if in {
+			section = append(section, line)
+		}
+	}
+
+	return strings.Join(section, "\n")
+}
+
+func parseRequirement(requirement string) (Dependency, bool) {
+	requirement = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(requirement), "-e "))
+	if requirement == "" || strings.HasPrefix(requirement, "#") || strings.HasPrefix(requirement, "-") {
+		return Dependency{}, false
+	}
+
+	// Direct references without a name are identified by their egg or repository name
+	if strings.HasPrefix(requirement, "git+") {
+		if m := pythonEgg.FindStringSubmatch(requirement); m != nil {
+			return Dependency{Name: m[1], Specifier: requirement}, true
+		}
+
+		repository := strings.Split(strings.Split(requirement, "#")[0], ".git")[0]
+		return Dependency{Name: path.Base(repository), Specifier: requirement}, true
+	}
+
+	m := pythonName.FindStringSubmatch(requirement)
+	if m == nil {
+		return Dependency{}, false
+	}
+
+	specifier := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(requirement[len(m[1]):]), "@"))
+
+	return Dependency{Name: m[1], Specifier: specifier}, true
+}
+
+func (p *Python) Dependencies() []Dependency {
+	var dependencies []Dependency
+
+	for _, line := range strings.Split(p.contents["requirements.txt"], "\n") {
+		if d, ok := parseRequirement(line); ok {
+			d.Section = "requirements.txt"
+			dependencies = append(dependencies, d)
+		}
+	}
+
+	// Dependencies in pyproject.toml are a list of quoted requirements under [project]
+	project := pyprojectSection(p.contents["pyproject.toml"], "project")
+	if i := strings.Index(project, "dependencies"); i >= 0 {
+		list := project[i:]
+		if end := strings.Index(list, "]"); end >= 0 {
+			list = list[:end]
+		}
+
+		for _, m := range pythonQuoted.FindAllStringSubmatch(list, -1) {
+			if d, ok := parseRequirement(m[1]); ok {
+				d.Section = "pyproject.toml"
+				dependencies = append(dependencies, d)
+			}
+		}
+	}
+
+	return dependencies
+}
+
+// gitReference matches a git URL for a project along with any @ref suffix
+func gitReference(project string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(git\+[^\s"'#]*/%s\.git)(@[^\s"'#]*)?`, regexp.QuoteMeta(project)))
+}
+
+func (p *Python) replace(project string, replacement func(url, ref string) string) {
+	r := gitReference(project)
+	for file, contents := range p.contents {
+		p.contents[file] = r.ReplaceAllStringFunc(contents, func(match string) string {
+			m := r.FindStringSubmatch(match)
+			return replacement(m[1], strings.TrimPrefix(m[2], "@"))
+		})
+	}
+}
+
+func (p *Python) SetDependencyBranchesToStory(story string, projects ...string) {
+	for _, project := range projects {
+		p.replace(project, func(url, ref string) string {
+			return fmt.Sprintf("%s@%s", url, story)
+		})
+	}
+}
+
+func (p *Python) SetDependencyBranchesToCommitHashes(story *manifest.Story, projects ...string) error {
+	for _, project := range projects {
+		hash, exists := story.Hashes[project]
+		if !exists {
+			continue
+		}
+
+		p.replace(project, func(url, ref string) string {
+			return fmt.Sprintf("%s@%s", url, hash)
+		})
+	}
+
+	return nil
+}
+
+func (p *Python) SetDependencyBranchesToRefs(refs map[string]string, projects ...string) {
+	for _, project := range projects {
+		ref, exists := refs[project]
+		if !exists {
+			continue
+		}
+
+		p.replace(project, func(url, _ string) string {
+			return fmt.Sprintf("%s@%s", url, ref)
+		})
+	}
+}
+
+func (p *Python) ResetDependencyBranchesToTrunk(story string) {
+	storyBranch := regexp.MustCompile(fmt.Sprintf(`(?m)\.git@%s([\s"'#]|$)`, regexp.QuoteMeta(story)))
+	for file, contents := range p.contents {
+		p.contents[file] = storyBranch.ReplaceAllString(contents, ".git$1")
+	}
+}
+
+func (p *Python) ResetDependencyBranches(toReset, story string) {
+	p.replace(toReset, func(url, ref string) string {
+		if ref == story || ref == "" {
+			return url
+		}
+
+		return fmt.Sprintf("%s@%s", url, ref)
+	})
+}
@@ -0,0 +1,155 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/manifest"
+)
+
+// Variable is an environment variable describing the state of a story
+type Variable struct {
+	Name  string
+	Value string
+}
+
+var invalidCharacters = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// HashVariable is the name of the variable holding the hash of a project
+func HashVariable(project string) string {
+	return fmt.Sprintf("STORY_HASH_%s", invalidCharacters.ReplaceAllString(strings.ToUpper(project), "_"))
+}
+
+// FromStory returns the variables of a story, sorted by name. An error is returned when the
+// hashes of two projects would be held by the same variable, such as for lib-a and lib_a.
+func FromStory(story *manifest.Story, trunk string) ([]Variable, error) {
+	var projects []string
+	for project := range story.Projects {
+		projects = append(projects, project)
+	}
+
+	blastRadius := make(map[string]bool)
+	for _, br := range story.BlastRadius {
+		for _, project := range br {
+			blastRadius[project] = true
+		}
+	}
+
+	variables := []Variable{
+		{Name: "STORY_NAME", Value: story.Name},
+		{Name: "STORY_ORGANISATION", Value: story.Orgranisation},
+		{Name: "STORY_TRUNK", Value: trunk},
+		{Name: "STORY_ARTIFACTS", Value: story.GetArtifacts()},
+		{Name: "STORY_PROJECTS", Value: join(projects)},
+		{Name: "STORY_BLAST_RADIUS", Value: join(keys(blastRadius))},
+	}
+
+	var hashed []string
+	for project := range story.Hashes {
+		hashed = append(hashed, project)
+	}
+
+	sort.Strings(hashed)
+
+	names := make(map[string]string)
+	for _, project := range hashed {
+		name := HashVariable(project)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("the hashes of %s and %s would both be exported as %s", other, project, name)
+		}
+
+		names[name] = project
+		variables = append(variables, Variable{Name: name, Value: story.Hashes[project]})
+	}
+
+	return sorted(variables), nil
+}
+
+// FromMeta returns the variables of trunk, where there are no story projects and every
+// artifact in the metarepo is deployable
+func FromMeta(meta *manifest.Meta, trunk string) []Variable {
+	artifacts := make(map[string]bool)
+	for artifact := range meta.Artifacts {
+		artifacts[artifact] = true
+	}
+
+	return sorted([]Variable{
+		{Name: "STORY_NAME", Value: ""},
+		{Name: "STORY_ORGANISATION", Value: meta.Organisation},
+		{Name: "STORY_TRUNK", Value: trunk},
+		{Name: "STORY_ARTIFACTS", Value: join(keys(artifacts))},
+		{Name: "STORY_PROJECTS", Value: ""},
+		{Name: "STORY_BLAST_RADIUS", Value: ""},
+	})
+}
+
+func keys(m map[string]bool) []string {
+	var k []string
+	for key := range m {
+		k = append(k, key)
+	}
+
+	return k
+}
+
+func join(values []string) string {
+	sort.Strings(values)
+	return strings.Join(values, " ")
+}
+
+func sorted(variables []Variable) []Variable {
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Name < variables[j].Name
+	})
+
+	return variables
+}
+
+// WriteShell writes the variables as export statements which can be evaluated by a shell
+func WriteShell(w io.Writer, variables []Variable) error {
+	for _, v := range variables {
+		quoted := strings.Replace(v.Value, "'", `'\''`, -1)
+		if _, err := fmt.Fprintf(w, "export %s='%s'\n", v.Name, quoted); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteDotenv writes the variables as a .env file, quoting values which contain spaces
+func WriteDotenv(w io.Writer, variables []Variable) error {
+	for _, v := range variables {
+		value := v.Value
+		if strings.ContainsAny(value, " \t#\"'") {
+			value = fmt.Sprintf("%q", value)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.Name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Object returns the variables as a map of names to values
+func Object(variables []Variable) map[string]string {
+	object := make(map[string]string)
+	for _, v := range variables {
+		object[v.Name] = v.Value
+	}
+
+	return object
+}
+
+// WriteJSON writes the variables as a JSON object
+func WriteJSON(w io.Writer, variables []Variable) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Object(variables))
+}
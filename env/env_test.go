@@ -0,0 +1,69 @@
+package env_test
+
+import (
+	"bytes"
+
+	"github.com/LGUG2Z/story/env"
+	"github.com/LGUG2Z/story/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Env", func() {
+	var story *manifest.Story
+
+	BeforeEach(func() {
+		story = &manifest.Story{
+			Name:          "sso-login",
+			Orgranisation: "test-org",
+			Projects:      map[string]string{"lib-1": "git@github.com:test-org/lib-1.git", "api": "git@github.com:test-org/api.git"},
+			Hashes:        map[string]string{"lib-1": "1111111111", "api": "2222222222"},
+			BlastRadius:   map[string][]string{"lib-1": {"web", "api"}, "api": nil},
+			Artifacts:     map[string]bool{"web": true, "api": true, "admin": false},
+		}
+	})
+
+	It("Should describe a story with sorted variables and values", func() {
+		variables, err := env.FromStory(story, "master")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(Equal([]env.Variable{
+			{Name: "STORY_ARTIFACTS", Value: "api web"},
+			{Name: "STORY_BLAST_RADIUS", Value: "api web"},
+			{Name: "STORY_HASH_API", Value: "2222222222"},
+			{Name: "STORY_HASH_LIB_1", Value: "1111111111"},
+			{Name: "STORY_NAME", Value: "sso-login"},
+			{Name: "STORY_ORGANISATION", Value: "test-org"},
+			{Name: "STORY_PROJECTS", Value: "api lib-1"},
+			{Name: "STORY_TRUNK", Value: "master"},
+		}))
+	})
+
+	It("Should return an error when the hashes of two projects map to the same variable", func() {
+		story.Hashes["lib_1"] = "3333333333"
+
+		_, err := env.FromStory(story, "master")
+		Expect(err).To(MatchError("the hashes of lib-1 and lib_1 would both be exported as STORY_HASH_LIB_1"))
+	})
+
+	It("Should describe trunk with every artifact", func() {
+		// Given a trunk .meta file, where artifacts are never set
+		meta := &manifest.Meta{Organisation: "test-org", Artifacts: map[string]bool{"web": false, "api": false}}
+
+		Expect(env.FromMeta(meta, "master")).To(ContainElement(env.Variable{Name: "STORY_ARTIFACTS", Value: "api web"}))
+		Expect(env.FromMeta(meta, "master")).To(ContainElement(env.Variable{Name: "STORY_NAME", Value: ""}))
+	})
+
+	It("Should write shell exports which quote values", func() {
+		var buf bytes.Buffer
+		Expect(env.WriteShell(&buf, []env.Variable{{Name: "STORY_NAME", Value: "it's"}, {Name: "STORY_PROJECTS", Value: "api lib-1"}})).To(Succeed())
+
+		Expect(buf.String()).To(Equal("export STORY_NAME='it'\\''s'\nexport STORY_PROJECTS='api lib-1'\n"))
+	})
+
+	It("Should write dotenv files which only quote values when needed", func() {
+		var buf bytes.Buffer
+		Expect(env.WriteDotenv(&buf, []env.Variable{{Name: "STORY_NAME", Value: "sso-login"}, {Name: "STORY_PROJECTS", Value: "api lib-1"}})).To(Succeed())
+
+		Expect(buf.String()).To(Equal("STORY_NAME=sso-login\nSTORY_PROJECTS=\"api lib-1\"\n"))
+	})
+})
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
 )
 
 type AddOpts struct {
@@ -19,6 +21,10 @@ func Add(opts AddOpts) (string, error) {
 		args = append(args, file)
 	}
 
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
@@ -0,0 +1,25 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Archive returns a tar archive of the files of a project at a commit
+func Archive(project, commit string) ([]byte, error) {
+	command := exec.Command("git", "archive", "--format=tar", commit)
+	if project != "" {
+		command.Dir = project
+	}
+
+	output, err := command.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s: %s", err, exitError.Stderr)
+		}
+
+		return nil, err
+	}
+
+	return output, nil
+}
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/LGUG2Z/story/dryrun"
 	"github.com/spf13/afero"
 )
 
@@ -24,6 +25,10 @@ func CheckoutBranch(opts CheckoutBranchOpts) (string, error) {
 
 	args = append(args, opts.Branch)
 
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
@@ -58,32 +63,40 @@ func DeleteBranch(opts DeleteBranchOpts) (string, error) {
 
 	if opts.Local {
 		args = append(args, "branch", "--delete", "--force", opts.Branch)
-		command := exec.Command("git", args...)
-		if opts.Project != "" {
-			command.Dir = opts.Project
-		}
+		if dryrun.Enabled() {
+			outputs = append(outputs, dryrun.Record(opts.Project, "git", args...))
+		} else {
+			command := exec.Command("git", args...)
+			if opts.Project != "" {
+				command.Dir = opts.Project
+			}
 
-		combinedOutput, err := command.CombinedOutput()
-		if err != nil {
-			return "", fmt.Errorf("%s: %s", err, combinedOutput)
-		}
+			combinedOutput, err := command.CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("%s: %s", err, combinedOutput)
+			}
 
-		outputs = append(outputs, strings.TrimSpace(string(combinedOutput)))
+			outputs = append(outputs, strings.TrimSpace(string(combinedOutput)))
+		}
 	}
 
 	if opts.Remote {
 		args = append(args, "push", "origin", "--delete", opts.Branch)
-		command := exec.Command("git", args...)
-		if opts.Project != "" {
-			command.Dir = opts.Project
-		}
+		if dryrun.Enabled() {
+			outputs = append(outputs, dryrun.Record(opts.Project, "git", args...))
+		} else {
+			command := exec.Command("git", args...)
+			if opts.Project != "" {
+				command.Dir = opts.Project
+			}
 
-		combinedOutput, err := command.CombinedOutput()
-		if err != nil {
-			return "", fmt.Errorf("%s: %s", err, combinedOutput)
-		}
+			combinedOutput, err := command.CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("%s: %s", err, combinedOutput)
+			}
 
-		outputs = append(outputs, strings.TrimSpace(string(combinedOutput)))
+			outputs = append(outputs, strings.TrimSpace(string(combinedOutput)))
+		}
 	}
 
 	return strings.Join(outputs, "\n"), nil
@@ -120,3 +133,131 @@ func getHead(fs afero.Fs, project, branch string) (string, error) {
 
 	return strings.TrimSpace(string(b)), nil
 }
+
+// HeadCommit returns the hash of the commit currently checked out in a project
+func HeadCommit(project string) (string, error) {
+	command := exec.Command("git", "rev-parse", "HEAD")
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.TrimSpace(string(combinedOutput)), nil
+}
+
+// Branches returns the commit at the head of every local branch of a project
+func Branches(project string) (map[string]string, error) {
+	command := exec.Command("git", "for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads")
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	branches := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(combinedOutput)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			branches[fields[0]] = fields[1]
+		}
+	}
+
+	return branches, nil
+}
+
+type SetBranchOpts struct {
+	Project string
+	Branch  string
+	// Commit is where the branch is moved to, and the branch is deleted when it is empty
+	Commit string
+	// Checkout also checks out the branch, keeping any uncommitted changes
+	Checkout bool
+}
+
+// SetBranch moves a local branch of a project to a commit, creating it if necessary
+func SetBranch(opts SetBranchOpts) (string, error) {
+	args := []string{"branch", "--force", opts.Branch, opts.Commit}
+	switch {
+	case opts.Commit == "":
+		args = []string{"branch", "--delete", "--force", opts.Branch}
+	case opts.Checkout:
+		args = []string{"checkout", "-B", opts.Branch, opts.Commit}
+	}
+
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
+	command := exec.Command("git", args...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.TrimSpace(string(combinedOutput)), nil
+}
+
+type DiscardChangesOpts struct {
+	Project string
+	Files   []string
+}
+
+// DiscardChanges checks out the committed contents of files, returning the files which
+// are not in the HEAD commit and so have nothing to go back to
+func DiscardChanges(opts DiscardChangesOpts) ([]string, error) {
+	command := exec.Command("git", append([]string{"ls-tree", "--name-only", "HEAD", "--"}, opts.Files...)...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	committed := make(map[string]bool)
+	for _, file := range strings.Fields(string(combinedOutput)) {
+		committed[file] = true
+	}
+
+	var tracked, untracked []string
+	for _, file := range opts.Files {
+		if committed[file] {
+			tracked = append(tracked, file)
+		} else {
+			untracked = append(untracked, file)
+		}
+	}
+
+	if len(tracked) == 0 {
+		return untracked, nil
+	}
+
+	args := append([]string{"checkout", "HEAD", "--"}, tracked...)
+	if dryrun.Enabled() {
+		dryrun.Record(opts.Project, "git", args...)
+		return untracked, nil
+	}
+
+	command = exec.Command("git", args...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	if combinedOutput, err := command.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return untracked, nil
+}
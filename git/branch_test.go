@@ -181,4 +181,35 @@ var _ = Describe("Branch", func() {
 			Expect(areEqual).To(BeTrue())
 		})
 	})
+
+	Describe("Setting branches", func() {
+		It("Should move, check out and delete branches", func() {
+			// Given a repository with a second commit on a new branch
+			head, err := git.HeadCommit("")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = git.CheckoutBranch(git.CheckoutBranchOpts{Create: true, Branch: "test-branch"})
+			Expect(err).NotTo(HaveOccurred())
+
+			command := exec.Command("git", "commit", "--allow-empty", "-m", "second")
+			_, err = command.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := git.HeadCommit("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(git.Branches("")).To(Equal(map[string]string{"master": head, "test-branch": second}))
+
+			// When I move master to the second commit and check it out
+			_, err = git.SetBranch(git.SetBranchOpts{Branch: "master", Commit: second, Checkout: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			// And I delete the new branch
+			_, err = git.SetBranch(git.SetBranchOpts{Branch: "test-branch"})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then only master is left, at the second commit
+			Expect(git.GetCurrentBranch(fs, ".")).To(Equal("master"))
+			Expect(git.Branches("")).To(Equal(map[string]string{"master": second}))
+		})
+	})
 })
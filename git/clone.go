@@ -1,29 +1,166 @@
 package git
 
 import (
+	"encoding/base64"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
+	"regexp"
 	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
 )
 
 type CloneOpts struct {
 	Repository string
 	Directory  string
+	// Branch is checked out instead of the default branch when it is set
+	Branch string
+	// Commit is checked out as a detached HEAD when it is set, instead of a branch
+	Commit string
+	// Depth makes a shallow clone with the given number of commits when it is set
+	Depth int
+	// Token authenticates HTTPS requests when it is set. It is sent as a header and is never
+	// stored in the clone or included in any output.
+	Token string
+}
+
+var urlCredentials = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// Redact removes the credentials of URLs and any of the given tokens from text
+func Redact(text string, tokens ...string) string {
+	text = urlCredentials.ReplaceAllString(text, "${1}***@")
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+
+		text = strings.Replace(text, token, "***", -1)
+		text = strings.Replace(text, basicCredentials(token), "***", -1)
+	}
+
+	return text
+}
+
+func basicCredentials(token string) string {
+	return base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+}
+
+// authenticated prefixes git arguments with a one-off authorization header for a token,
+// which unlike credentials in the remote URL is not written to .git/config
+func authenticated(token string, args ...string) []string {
+	if token == "" {
+		return args
+	}
+
+	header := fmt.Sprintf("http.extraheader=Authorization: Basic %s", basicCredentials(token))
+	return append([]string{"-c", header}, args...)
 }
 
 func Clone(opts CloneOpts) (string, error) {
+	if opts.Commit != "" {
+		return cloneCommit(opts)
+	}
+
 	var args []string
 	args = append(args, "clone", opts.Repository)
 
-	command := exec.Command("git", args...)
+	if opts.Branch != "" {
+		args = append(args, "--branch", opts.Branch)
+	}
+
+	if opts.Depth > 0 {
+		args = append(args, "--depth", fmt.Sprintf("%d", opts.Depth))
+	}
+
 	if opts.Directory != "" {
-		command.Args = append(command.Args, opts.Directory)
+		args = append(args, opts.Directory)
+	}
+
+	if dryrun.Enabled() {
+		return dryrun.Record(cloneDirectory(opts), "git", redacted(args)...), nil
 	}
 
+	command := exec.Command("git", authenticated(opts.Token, args...)...)
+
 	combinedOutput, err := command.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+		return "", fmt.Errorf("%s", Redact(fmt.Sprintf("%s: %s", err, combinedOutput), opts.Token))
+	}
+
+	return Redact(strings.TrimSpace(string(combinedOutput)), opts.Token), nil
+}
+
+// cloneDirectory returns the directory a repository is cloned into
+func cloneDirectory(opts CloneOpts) string {
+	if opts.Directory != "" {
+		return opts.Directory
+	}
+
+	return strings.TrimSuffix(path.Base(opts.Repository), ".git")
+}
+
+// redacted returns git arguments with the credentials of URLs removed. Tokens are never
+// in the arguments, as they are only added by authenticated.
+func redacted(args []string) []string {
+	var redacted []string
+	for _, arg := range args {
+		redacted = append(redacted, Redact(arg))
+	}
+
+	return redacted
+}
+
+// cloneCommit fetches a single commit into a new repository, as git clone can only
+// check out branches and tags
+func cloneCommit(opts CloneOpts) (string, error) {
+	fetch := []string{"fetch", "origin", opts.Commit}
+	if opts.Depth > 0 {
+		fetch = append(fetch, "--depth", fmt.Sprintf("%d", opts.Depth))
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", opts.Repository},
+		fetch,
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	}
+
+	directory := cloneDirectory(opts)
+	if dryrun.Enabled() {
+		var outputs []string
+		for _, args := range steps {
+			outputs = append(outputs, dryrun.Record(directory, "git", redacted(args)...))
+		}
+
+		return strings.Join(outputs, "\n"), nil
+	}
+
+	if err := os.MkdirAll(directory, os.FileMode(0700)); err != nil {
+		return "", err
 	}
 
-	return strings.TrimSpace(string(combinedOutput)), nil
+	var outputs []string
+	for _, args := range steps {
+		if args[0] == "fetch" {
+			args = authenticated(opts.Token, args...)
+		}
+
+		command := exec.Command("git", args...)
+		command.Dir = directory
+
+		combinedOutput, err := command.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("%s", Redact(fmt.Sprintf("%s: %s", err, combinedOutput), opts.Token))
+		}
+
+		if output := Redact(strings.TrimSpace(string(combinedOutput)), opts.Token); output != "" {
+			outputs = append(outputs, output)
+		}
+	}
+
+	outputs = append(outputs, fmt.Sprintf("checked out %s", opts.Commit))
+
+	return strings.Join(outputs, "\n"), nil
 }
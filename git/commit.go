@@ -3,7 +3,11 @@ package git
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/LGUG2Z/story/dryrun"
 )
 
 type CommitOpts struct {
@@ -12,6 +16,15 @@ type CommitOpts struct {
 }
 
 func hasStagedChanges(project string) (bool, error) {
+	files, err := StagedFiles(project)
+	if err != nil {
+		return false, err
+	}
+
+	return len(files) > 0, nil
+}
+
+func StagedFiles(project string) ([]string, error) {
 	command := exec.Command("git", "diff", "--cached", "--name-only")
 	if project != "" {
 		command.Dir = project
@@ -19,24 +32,34 @@ func hasStagedChanges(project string) (bool, error) {
 
 	combinedOutput, err := command.CombinedOutput()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	trimmed := strings.TrimSpace(string(combinedOutput))
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
 
-	return len(trimmed) > 0, nil
+	return strings.Split(trimmed, "\n"), nil
 }
 
-func Commit(opts CommitOpts) (string, error) {
-	changes, err := hasStagedChanges(opts.Project)
-	if err != nil {
-		return "", err
+// HasChanges reports whether a project has uncommitted changes, including untracked files
+// which are not ignored
+func HasChanges(project string) (bool, error) {
+	command := exec.Command("git", "status", "--porcelain")
+	if project != "" {
+		command.Dir = project
 	}
 
-	if !changes {
-		return "no staged changes to commit", err
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", err, combinedOutput)
 	}
 
+	return len(strings.TrimSpace(string(combinedOutput))) > 0, nil
+}
+
+func Commit(opts CommitOpts) (string, error) {
 	var args []string
 	args = append(args, "commit")
 
@@ -45,6 +68,20 @@ func Commit(opts CommitOpts) (string, error) {
 		args = append(args, message)
 	}
 
+	// Files are only recorded as staged when dry running, so there is nothing to look for
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
+	changes, err := hasStagedChanges(opts.Project)
+	if err != nil {
+		return "", err
+	}
+
+	if !changes {
+		return "no staged changes to commit", err
+	}
+
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
@@ -57,3 +94,22 @@ func Commit(opts CommitOpts) (string, error) {
 
 	return strings.TrimSpace(string(combinedOutput)), nil
 }
+
+func CommitTime(project, hash string) (time.Time, error) {
+	command := exec.Command("git", "show", "--no-patch", "--format=%ct", hash)
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(combinedOutput)), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
@@ -51,6 +51,22 @@ var _ = Describe("Commit", func() {
 		})
 	})
 
+	Describe("Listing staged files", func() {
+		It("Should list the files staged for the next commit", func() {
+			// Given a repository with files staged for commit
+			Expect(afero.WriteFile(fs, "package.json", []byte{}, os.FileMode(0666))).To(Succeed())
+			_, err := git.Add(git.AddOpts{Files: []string{"package.json"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I list the staged files
+			files, err := git.StagedFiles("")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then the staged file is listed
+			Expect(files).To(Equal([]string{"package.json"}))
+		})
+	})
+
 	Describe("Committing with no staged files", func() {
 		It("Should return a message informing that there is nothing to commit", func() {
 			// Given a repository without files staged for commit
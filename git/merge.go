@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
 )
 
 type FetchOpts struct {
@@ -16,6 +18,10 @@ func Fetch(opts FetchOpts) (string, error) {
 	var args []string
 	args = append(args, "fetch", opts.Remote, fmt.Sprintf("%s:%s", opts.Branch, opts.Branch))
 
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
@@ -45,6 +51,10 @@ func Merge(opts MergeOpts) (string, error) {
 
 	args = append(args, opts.SourceBranch)
 
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
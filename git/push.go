@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
 )
 
 type PushOpts struct {
@@ -29,6 +31,14 @@ func hasUnpushedCommits(project string) (bool, error) {
 }
 
 func Push(opts PushOpts) (string, error) {
+	var args []string
+	args = append(args, "push", "-u", opts.Remote, opts.Branch)
+
+	// Commits are only recorded when dry running, so there is nothing to look for
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
 	changes, err := hasUnpushedCommits(opts.Project)
 	if err != nil {
 		return "", err
@@ -38,9 +48,6 @@ func Push(opts PushOpts) (string, error) {
 		return "no unpushed commits", err
 	}
 
-	var args []string
-	args = append(args, "push", "-u", opts.Remote, opts.Branch)
-
 	command := exec.Command("git", args...)
 	if opts.Project != "" {
 		command.Dir = opts.Project
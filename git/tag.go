@@ -0,0 +1,203 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
+)
+
+type TagOpts struct {
+	Project string
+	Name    string
+	Message string
+	Commit  string
+}
+
+// Tag creates an annotated tag on a commit of a project, or on the current commit if none is given
+func Tag(opts TagOpts) (string, error) {
+	message := opts.Message
+	if message == "" {
+		message = opts.Name
+	}
+
+	args := []string{"tag", "--annotate", opts.Name, "--message", message}
+	if opts.Commit != "" {
+		args = append(args, opts.Commit)
+	}
+
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
+	command := exec.Command("git", args...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return fmt.Sprintf("tagged %s", opts.Name), nil
+}
+
+// LatestTag returns the most recent tag matching a pattern that is reachable from
+// the current commit of a project, or an empty string if there isn't one
+func LatestTag(project, pattern string) (string, error) {
+	command := exec.Command("git", "describe", "--tags", "--abbrev=0", "--match", pattern)
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		// git describe fails when there are no matching tags
+		if strings.Contains(string(combinedOutput), "No names found") || strings.Contains(string(combinedOutput), "No tags can describe") {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.TrimSpace(string(combinedOutput)), nil
+}
+
+// Messages returns the messages of the commits in a project since a ref, or of
+// every commit if the ref is empty
+func Messages(project, since string) ([]string, error) {
+	args := []string{"log", "--format=%B%x00"}
+	if since != "" {
+		args = append(args, fmt.Sprintf("%s..HEAD", since))
+	}
+
+	command := exec.Command("git", args...)
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	var messages []string
+	for _, message := range strings.Split(string(combinedOutput), "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Tags returns the local tags of a project matching a pattern
+func Tags(project, pattern string) ([]string, error) {
+	command := exec.Command("git", "tag", "--list", pattern)
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.Fields(string(combinedOutput)), nil
+}
+
+// RemoteTags returns the tags of a project on a remote matching a pattern
+func RemoteTags(project, remote, pattern string) ([]string, error) {
+	command := exec.Command("git", "ls-remote", "--tags", "--refs", remote, fmt.Sprintf("refs/tags/%s", pattern))
+	if project != "" {
+		command.Dir = project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	var tags []string
+	for _, line := range strings.Split(strings.TrimSpace(string(combinedOutput)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			tags = append(tags, strings.TrimPrefix(fields[1], "refs/tags/"))
+		}
+	}
+
+	return tags, nil
+}
+
+type PushTagsOpts struct {
+	Project string
+	Remote  string
+	Tags    []string
+}
+
+func PushTags(opts PushTagsOpts) (string, error) {
+	args := []string{"push", opts.Remote}
+	for _, tag := range opts.Tags {
+		args = append(args, fmt.Sprintf("refs/tags/%s", tag))
+	}
+
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
+	command := exec.Command("git", args...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.TrimSpace(string(combinedOutput)), nil
+}
+
+type DeleteTagsOpts struct {
+	Project string
+	Remote  string
+	Tags    []string
+}
+
+// DeleteTags deletes tags from a remote if one is given, and otherwise deletes local tags
+func DeleteTags(opts DeleteTagsOpts) (string, error) {
+	if len(opts.Tags) == 0 {
+		return "no tags to delete", nil
+	}
+
+	args := []string{"tag", "--delete"}
+	if opts.Remote != "" {
+		args = []string{"push", opts.Remote, "--delete"}
+	}
+
+	for _, tag := range opts.Tags {
+		if opts.Remote != "" {
+			tag = fmt.Sprintf("refs/tags/%s", tag)
+		}
+
+		args = append(args, tag)
+	}
+
+	if dryrun.Enabled() {
+		return dryrun.Record(opts.Project, "git", args...), nil
+	}
+
+	command := exec.Command("git", args...)
+	if opts.Project != "" {
+		command.Dir = opts.Project
+	}
+
+	combinedOutput, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, combinedOutput)
+	}
+
+	return strings.TrimSpace(string(combinedOutput)), nil
+}
@@ -0,0 +1,78 @@
+package git_test
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/git"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Tag", func() {
+	BeforeEach(func() {
+		if err := fs.MkdirAll("test", os.FileMode(0700)); err != nil {
+			Fail(err.Error())
+		}
+
+		if err := os.Chdir("test"); err != nil {
+			Fail(err.Error())
+		}
+
+		if err := initialiseRepository("."); err != nil {
+			Fail(err.Error())
+		}
+	})
+
+	AfterEach(func() {
+		if err := os.Chdir(".."); err != nil {
+			Fail(err.Error())
+		}
+
+		if err := fs.RemoveAll("test"); err != nil {
+			Fail(err.Error())
+		}
+	})
+
+	Describe("Finding the latest tag", func() {
+		It("Should return an empty string when there are no tags", func() {
+			tag, err := git.LatestTag("", "v*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tag).To(BeEmpty())
+		})
+
+		It("Should return the most recent matching tag", func() {
+			// Given a tagged commit
+			_, err := git.Tag(git.TagOpts{Name: "v1.0.0"})
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I look for the latest tag
+			tag, err := git.LatestTag("", "v*")
+
+			// Then the tag is returned
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tag).To(Equal("v1.0.0"))
+		})
+	})
+
+	Describe("Listing commit messages", func() {
+		It("Should only list the messages of commits since a ref", func() {
+			// Given a tagged commit followed by another commit
+			_, err := git.Tag(git.TagOpts{Name: "v1.0.0"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(afero.WriteFile(fs, "feature", []byte{}, os.FileMode(0666))).To(Succeed())
+			_, err = git.Add(git.AddOpts{Files: []string{"feature"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = git.Commit(git.CommitOpts{Messages: []string{"feat: add feature", "BREAKING CHANGE: removes the old feature"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			// When I list the messages since the tag
+			messages, err := git.Messages("", "v1.0.0")
+
+			// Then only the new commit is listed
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(Equal([]string{"feat: add feature\n\nBREAKING CHANGE: removes the old feature"}))
+		})
+	})
+})
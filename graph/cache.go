@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/spf13/afero"
+)
+
+// CacheFile is the default location of the graph cache, relative to the metarepo
+const CacheFile = ".git/story/graph.json"
+
+type entry struct {
+	Hash         string                 `json:"hash"`
+	Ecosystem    string                 `json:"ecosystem"`
+	Name         string                 `json:"name,omitempty"`
+	Dependencies []ecosystem.Dependency `json:"dependencies,omitempty"`
+}
+
+type cache struct {
+	Projects map[string]entry `json:"projects"`
+}
+
+func loadCache(fs afero.Fs, filename string) (*cache, error) {
+	c := &cache{}
+
+	exists, err := afero.Exists(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return c, nil
+	}
+
+	b, err := afero.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// A corrupt cache is discarded rather than failing the build
+	if err := json.Unmarshal(b, c); err != nil {
+		return &cache{}, nil
+	}
+
+	return c, nil
+}
+
+// get returns the cached entry for a project if its manifest files are unchanged,
+// and otherwise loads them with the adapter for the project's ecosystem
+func (c *cache) get(fs afero.Fs, adapter ecosystem.Adapter, path, project string) (entry, error) {
+	h := sha256.New()
+	for _, file := range adapter.Files() {
+		filename := fmt.Sprintf("%s/%s", path, file)
+		exists, err := afero.Exists(fs, filename)
+		if err != nil {
+			return entry{}, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		b, err := afero.ReadFile(fs, filename)
+		if err != nil {
+			return entry{}, err
+		}
+
+		fmt.Fprintf(h, "%s\x00", file)
+		h.Write(b)
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	if e, ok := c.Projects[project]; ok && e.Hash == hash && e.Ecosystem == adapter.Ecosystem() {
+		return e, nil
+	}
+
+	if err := adapter.Load(fs, path); err != nil {
+		return entry{}, fmt.Errorf("%s: %s", project, err)
+	}
+
+	return entry{
+		Hash:         hash,
+		Ecosystem:    adapter.Ecosystem(),
+		Name:         adapter.Name(),
+		Dependencies: adapter.Dependencies(),
+	}, nil
+}
+
+func (c *cache) write(fs afero.Fs, filename string, packages map[string]entry) error {
+	if err := fs.MkdirAll(filepath.Dir(filename), os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(&cache{Projects: packages}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, filename, b, os.FileMode(0666))
+}
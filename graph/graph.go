@@ -0,0 +1,462 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/spf13/afero"
+)
+
+const (
+	Dependencies    = "dependencies"
+	DevDependencies = "devDependencies"
+)
+
+// Edge is a dependency of one metarepo project on another, as declared
+// in a section of the dependent project's manifest
+type Edge struct {
+	From      string `json:"from" yaml:"from"`
+	To        string `json:"to" yaml:"to"`
+	Section   string `json:"section" yaml:"section"`
+	Specifier string `json:"specifier" yaml:"specifier"`
+}
+
+type Graph struct {
+	Projects     []string
+	Packages     map[string]string
+	dependencies map[string][]Edge
+	dependents   map[string][]Edge
+}
+
+type BuildOpts struct {
+	Metarepo  string
+	CacheFile string
+	// Projects limits the graph to the given projects, so that no other manifests are read
+	Projects []string
+	// Organisation owns the npm scope whose packages may be provided by projects that do
+	// not declare the scoped name, and is read from the .meta file if not given
+	Organisation string
+}
+
+// Build creates the dependency graph of every project in the metarepo that has a
+// manifest supported by one of the ecosystem adapters. If a cache file is given,
+// manifests whose contents have not changed since the last build are not parsed again.
+func Build(fs afero.Fs, opts BuildOpts) (*Graph, error) {
+	metarepo := opts.Metarepo
+	if metarepo == "" {
+		metarepo = "."
+	}
+
+	organisation := opts.Organisation
+	if organisation == "" {
+		var err error
+		if organisation, err = readOrganisation(fs, metarepo); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &cache{}
+	if opts.CacheFile != "" {
+		var err error
+		if c, err = loadCache(fs, filepath.Join(metarepo, opts.CacheFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	projects := opts.Projects
+	if len(projects) == 0 {
+		infos, err := afero.ReadDir(fs, metarepo)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, info := range infos {
+			if info.IsDir() && !strings.HasPrefix(info.Name(), ".") && info.Name() != "node_modules" {
+				projects = append(projects, info.Name())
+			}
+		}
+	}
+
+	packages := make(map[string]entry)
+	for _, project := range projects {
+		path := filepath.Join(metarepo, project)
+		adapter, err := ecosystem.Detect(fs, path)
+		if err != nil {
+			continue
+		}
+
+		e, err := c.get(fs, adapter, path, project)
+		if err != nil {
+			return nil, err
+		}
+
+		packages[project] = e
+	}
+
+	if opts.CacheFile != "" {
+		// The cached entries of projects which were not read are kept
+		entries := packages
+		if len(opts.Projects) > 0 {
+			entries = make(map[string]entry)
+			for project, e := range c.Projects {
+				entries[project] = e
+			}
+
+			for project, e := range packages {
+				entries[project] = e
+			}
+		}
+
+		if err := c.write(fs, filepath.Join(metarepo, opts.CacheFile), entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return newGraph(packages, organisation), nil
+}
+
+// readOrganisation returns the organisation in the .meta file of a metarepo, if it has one
+func readOrganisation(fs afero.Fs, metarepo string) (string, error) {
+	b, err := afero.ReadFile(fs, filepath.Join(metarepo, ".meta"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	var meta struct {
+		Organisation string `json:"organisation"`
+	}
+
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return "", err
+	}
+
+	return meta.Organisation, nil
+}
+
+func newGraph(packages map[string]entry, organisation string) *Graph {
+	g := &Graph{
+		Packages:     make(map[string]string),
+		dependencies: make(map[string][]Edge),
+		dependents:   make(map[string][]Edge),
+	}
+
+	byName := make(map[string]string)
+	for project, e := range packages {
+		g.Projects = append(g.Projects, project)
+
+		name := e.Name
+		if name == "" {
+			name = project
+		}
+
+		g.Packages[project] = name
+		byName[name] = project
+	}
+
+	sort.Strings(g.Projects)
+
+	for _, from := range g.Projects {
+		for _, d := range packages[from].Dependencies {
+			to, ok := resolve(byName, packages, organisation, d.Name)
+			if !ok || to == from {
+				continue
+			}
+
+			edge := Edge{From: from, To: to, Section: d.Section, Specifier: d.Specifier}
+			g.dependencies[from] = append(g.dependencies[from], edge)
+			g.dependents[to] = append(g.dependents[to], edge)
+		}
+	}
+
+	return g
+}
+
+// resolve finds the metarepo project providing a package by the name it declares, falling
+// back to the project directory matching the unscoped name of the organisation's npm packages
+func resolve(byName map[string]string, packages map[string]entry, organisation, name string) (string, bool) {
+	if project, ok := byName[name]; ok {
+		return project, true
+	}
+
+	project, ok := ProjectName(organisation, name)
+	if !ok {
+		return "", false
+	}
+
+	if _, ok := packages[project]; ok {
+		return project, true
+	}
+
+	return "", false
+}
+
+// ProjectName is the project directory expected to provide a package in the organisation's
+// npm scope, which is its unscoped name. Packages from any other scope, or none, are not
+// expected to be provided by the metarepo.
+func ProjectName(organisation, pkg string) (string, bool) {
+	scope := fmt.Sprintf("@%s/", organisation)
+	if organisation == "" || !strings.HasPrefix(pkg, scope) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(pkg, scope), true
+}
+
+func (g *Graph) Has(project string) bool {
+	_, ok := g.Packages[project]
+	return ok
+}
+
+// Dependencies returns the edges from a project to the metarepo projects it depends on
+func (g *Graph) Dependencies(project string) []Edge {
+	return g.dependencies[project]
+}
+
+// Dependents returns the edges to a project from the metarepo projects that depend on it
+func (g *Graph) Dependents(project string) []Edge {
+	return g.dependents[project]
+}
+
+// TransitiveDependents returns every project that directly or indirectly depends on
+// a project, up to the given depth. A depth of 0 or less means there is no limit.
+func (g *Graph) TransitiveDependents(project string, depth int) []string {
+	return g.walk(project, depth, func(p string) []string {
+		var next []string
+		for _, e := range g.dependents[p] {
+			next = append(next, e.From)
+		}
+
+		return next
+	})
+}
+
+// TransitiveDependencies returns every project that a project directly or indirectly
+// depends on, up to the given depth. A depth of 0 or less means there is no limit.
+func (g *Graph) TransitiveDependencies(project string, depth int) []string {
+	return g.walk(project, depth, func(p string) []string {
+		var next []string
+		for _, e := range g.dependencies[p] {
+			next = append(next, e.To)
+		}
+
+		return next
+	})
+}
+
+// TransitiveDependenciesInSection returns every project that a project directly or
+// indirectly depends on through one section of its manifest, such as Dependencies, up to
+// the given depth. A depth of 0 or less means there is no limit.
+func (g *Graph) TransitiveDependenciesInSection(project, section string, depth int) []string {
+	return g.walk(project, depth, func(p string) []string {
+		var next []string
+		for _, e := range g.dependencies[p] {
+			if e.Section == section {
+				next = append(next, e.To)
+			}
+		}
+
+		return next
+	})
+}
+
+func (g *Graph) walk(project string, depth int, next func(string) []string) []string {
+	seen := map[string]bool{project: true}
+	var found []string
+
+	frontier := []string{project}
+	for level := 1; len(frontier) > 0 && (depth <= 0 || level <= depth); level++ {
+		var following []string
+		for _, p := range frontier {
+			for _, n := range next(p) {
+				if seen[n] {
+					continue
+				}
+
+				seen[n] = true
+				found = append(found, n)
+				following = append(following, n)
+			}
+		}
+
+		frontier = following
+	}
+
+	sort.Strings(found)
+
+	return found
+}
+
+// Paths returns every path of dependency edges that leads from one project to another
+func (g *Graph) Paths(from, to string) [][]Edge {
+	var paths [][]Edge
+	var path []Edge
+	onPath := map[string]bool{from: true}
+
+	var visit func(project string)
+	visit = func(project string) {
+		for _, e := range g.dependencies[project] {
+			if onPath[e.To] {
+				continue
+			}
+
+			path = append(path, e)
+			if e.To == to {
+				paths = append(paths, append([]Edge{}, path...))
+			} else {
+				onPath[e.To] = true
+				visit(e.To)
+				onPath[e.To] = false
+			}
+
+			path = path[:len(path)-1]
+		}
+	}
+
+	if from != to {
+		visit(from)
+	}
+
+	return paths
+}
+
+// Cycles returns every group of projects that depend on each other, directly or
+// indirectly, as sorted lists of project names
+func (g *Graph) Cycles() [][]string {
+	t := &tarjan{graph: g, index: make(map[string]int), low: make(map[string]int), onStack: make(map[string]bool)}
+	for _, project := range g.Projects {
+		if _, visited := t.index[project]; !visited {
+			t.connect(project)
+		}
+	}
+
+	sort.Slice(t.cycles, func(i, j int) bool {
+		return t.cycles[i][0] < t.cycles[j][0]
+	})
+
+	return t.cycles
+}
+
+// Waves groups projects so that every project only depends on projects in earlier
+// waves. Projects that are part of a cycle can never be ordered and form the last wave.
+func (g *Graph) Waves() [][]string {
+	remaining := make(map[string]int)
+	for _, project := range g.Projects {
+		for _, e := range g.dependencies[project] {
+			if e.To != project {
+				remaining[project]++
+			}
+		}
+	}
+
+	done := make(map[string]bool)
+	var waves [][]string
+	for len(done) < len(g.Projects) {
+		var wave []string
+		for _, project := range g.Projects {
+			if !done[project] && remaining[project] == 0 {
+				wave = append(wave, project)
+			}
+		}
+
+		if len(wave) == 0 {
+			for _, project := range g.Projects {
+				if !done[project] {
+					wave = append(wave, project)
+				}
+			}
+		}
+
+		for _, project := range wave {
+			done[project] = true
+			for _, e := range g.dependents[project] {
+				remaining[e.From]--
+			}
+		}
+
+		waves = append(waves, wave)
+	}
+
+	return waves
+}
+
+type tarjan struct {
+	graph   *Graph
+	counter int
+	index   map[string]int
+	low     map[string]int
+	stack   []string
+	onStack map[string]bool
+	cycles  [][]string
+}
+
+func (t *tarjan) connect(project string) {
+	t.index[project] = t.counter
+	t.low[project] = t.counter
+	t.counter++
+	t.stack = append(t.stack, project)
+	t.onStack[project] = true
+
+	for _, e := range t.graph.dependencies[project] {
+		if _, visited := t.index[e.To]; !visited {
+			t.connect(e.To)
+			if t.low[e.To] < t.low[project] {
+				t.low[project] = t.low[e.To]
+			}
+		} else if t.onStack[e.To] && t.index[e.To] < t.low[project] {
+			t.low[project] = t.index[e.To]
+		}
+	}
+
+	if t.low[project] != t.index[project] {
+		return
+	}
+
+	var component []string
+	for {
+		p := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[p] = false
+		component = append(component, p)
+
+		if p == project {
+			break
+		}
+	}
+
+	if len(component) > 1 {
+		sort.Strings(component)
+		t.cycles = append(t.cycles, component)
+	}
+}
+
+// Calculator calculates blast radiuses using the metarepo dependency graph
+type Calculator struct {
+	Depth     int
+	CacheFile string
+}
+
+func NewCalculator() *Calculator {
+	return &Calculator{CacheFile: CacheFile}
+}
+
+func (c *Calculator) Calculate(fs afero.Fs, metarepo, project string) ([]string, error) {
+	g, err := Build(fs, BuildOpts{Metarepo: metarepo, CacheFile: c.CacheFile})
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.Has(project) {
+		return nil, nil
+	}
+
+	return g.TransitiveDependents(project, c.Depth), nil
+}
@@ -0,0 +1,264 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/LGUG2Z/story/graph"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var fs afero.Fs
+
+func writePackageJSON(project, name string, dependencies, devDependencies map[string]string) {
+	p := map[string]interface{}{"name": name}
+	if dependencies != nil {
+		p["dependencies"] = dependencies
+	}
+
+	if devDependencies != nil {
+		p["devDependencies"] = devDependencies
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(fs.MkdirAll(project, os.FileMode(0700))).To(Succeed())
+	Expect(afero.WriteFile(fs, fmt.Sprintf("%s/package.json", project), b, os.FileMode(0666))).To(Succeed())
+}
+
+func gitDependency(project string) string {
+	return fmt.Sprintf("git+ssh://git@github.com:test-org/%s.git", project)
+}
+
+var _ = Describe("Graph", func() {
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+
+		// Given a metarepo where api depends on lib-1, lib-1 depends on the scoped lib-2,
+		// and app depends on lib-2 only as a dev dependency
+		writePackageJSON("lib-2", "@test-org/lib-2", nil, nil)
+		writePackageJSON("lib-1", "lib-1", map[string]string{"@test-org/lib-2": gitDependency("lib-2"), "lodash": "^4.0.0"}, nil)
+		writePackageJSON("api", "api", map[string]string{"lib-1": gitDependency("lib-1")}, nil)
+		writePackageJSON("app", "app", nil, map[string]string{"@test-org/lib-2": gitDependency("lib-2")})
+		Expect(fs.MkdirAll("not-node", os.FileMode(0700))).To(Succeed())
+	})
+
+	Describe("Building the graph", func() {
+		It("Should include every project with a package.json", func() {
+			// When I build the graph
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then only node projects are in the graph
+			Expect(g.Projects).To(Equal([]string{"api", "app", "lib-1", "lib-2"}))
+			Expect(g.Packages).To(HaveKeyWithValue("lib-2", "@test-org/lib-2"))
+		})
+
+		It("Should label edges with the package.json section and specifier", func() {
+			// When I build the graph
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then scoped and dev dependencies on other projects are edges, and external packages are not
+			Expect(g.Dependencies("lib-1")).To(Equal([]graph.Edge{{From: "lib-1", To: "lib-2", Section: "dependencies", Specifier: gitDependency("lib-2")}}))
+			Expect(g.Dependencies("app")).To(Equal([]graph.Edge{{From: "app", To: "lib-2", Section: "devDependencies", Specifier: gitDependency("lib-2")}}))
+		})
+
+		It("Should include projects from other ecosystems", func() {
+			// Given a Go service which requires a Go library in the metarepo
+			Expect(fs.MkdirAll("go-lib", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "go-lib/go.mod", []byte("module github.com/test-org/go-lib/v2\n"), os.FileMode(0666))).To(Succeed())
+			Expect(fs.MkdirAll("go-api", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "go-api/go.mod", []byte("module github.com/test-org/go-api\n\nrequire github.com/test-org/go-lib/v2 v2.1.0\nrequire github.com/pkg/errors v0.8.1\n"), os.FileMode(0666))).To(Succeed())
+
+			// And a project whose directory has the same name as a third-party module
+			Expect(fs.MkdirAll("errors", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "errors/go.mod", []byte("module github.com/test-org/errors\n"), os.FileMode(0666))).To(Succeed())
+
+			// When I build the graph
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then the Go projects are connected by their declared module paths only
+			Expect(g.Dependencies("go-api")).To(Equal([]graph.Edge{{From: "go-api", To: "go-lib", Section: "require", Specifier: "v2.1.0"}}))
+			Expect(g.TransitiveDependents("go-lib", 0)).To(Equal([]string{"go-api"}))
+		})
+
+		It("Should only match packages in other scopes by the names projects declare", func() {
+			// Given the organisation's lib-3 is unnamed, a project named core, and an app which
+			// depends on both and on third-party scoped packages
+			Expect(afero.WriteFile(fs, ".meta", []byte(`{"organisation": "test-org"}`), os.FileMode(0666))).To(Succeed())
+			writePackageJSON("lib-3", "", nil, nil)
+			writePackageJSON("core", "core", nil, nil)
+			writePackageJSON("app", "app", map[string]string{"@test-org/lib-3": "^1.0.0", "@babel/core": "^7.0.0", "@types/node": "^12.0.0"}, nil)
+
+			// When I build the graph
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then only the organisation's scoped package is provided by a project
+			Expect(g.Dependencies("app")).To(Equal([]graph.Edge{{From: "app", To: "lib-3", Section: "dependencies", Specifier: "^1.0.0"}}))
+			Expect(g.TransitiveDependents("core", 0)).To(BeEmpty())
+		})
+	})
+
+	Describe("Building the graph of some projects", func() {
+		It("Should not read the manifests of other projects", func() {
+			// Given a project with an invalid manifest
+			Expect(fs.MkdirAll("broken", os.FileMode(0700))).To(Succeed())
+			Expect(afero.WriteFile(fs, "broken/package.json", []byte(`{"dependencies": ["lib-1"]}`), os.FileMode(0666))).To(Succeed())
+
+			// When I build the graph of lib-1 and lib-2
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", Projects: []string{"lib-1", "lib-2"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then only they are in the graph
+			Expect(g.Projects).To(Equal([]string{"lib-1", "lib-2"}))
+			Expect(g.TransitiveDependents("lib-2", 0)).To(Equal([]string{"lib-1"}))
+		})
+	})
+
+	Describe("Calculating transitive dependents", func() {
+		It("Should include indirect dependents when there is no depth limit", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.TransitiveDependents("lib-2", 0)).To(Equal([]string{"api", "app", "lib-1"}))
+		})
+
+		It("Should only include dependents up to the given depth", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.TransitiveDependents("lib-2", 1)).To(Equal([]string{"app", "lib-1"}))
+		})
+
+		It("Should calculate the blast radius of a project", func() {
+			// Given a calculator
+			c := graph.NewCalculator()
+
+			// When I calculate the blast radius of a project
+			br, err := c.Calculate(fs, ".", "lib-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then it contains the projects which depend on it
+			Expect(br).To(Equal([]string{"api"}))
+		})
+	})
+
+	Describe("Calculating transitive dependencies", func() {
+		It("Should include dependencies from every section", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.TransitiveDependencies("api", 0)).To(Equal([]string{"lib-1", "lib-2"}))
+			Expect(g.TransitiveDependencies("app", 0)).To(Equal([]string{"lib-2"}))
+		})
+
+		It("Should only follow dependencies from the given section", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.TransitiveDependenciesInSection("api", graph.Dependencies, 0)).To(Equal([]string{"lib-1", "lib-2"}))
+			Expect(g.TransitiveDependenciesInSection("app", graph.Dependencies, 0)).To(BeEmpty())
+		})
+	})
+
+	Describe("Finding dependency paths", func() {
+		It("Should return every path from a project to one of its transitive dependencies", func() {
+			// Given api also depends on lib-2 directly
+			writePackageJSON("api", "api", map[string]string{"lib-1": gitDependency("lib-1")}, map[string]string{"@test-org/lib-2": "^1.0.0"})
+
+			// When I find the paths from api to lib-2
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+			paths := g.Paths("api", "lib-2")
+
+			// Then both the direct and the indirect paths are returned
+			Expect(paths).To(ConsistOf(
+				[]graph.Edge{
+					{From: "api", To: "lib-1", Section: "dependencies", Specifier: gitDependency("lib-1")},
+					{From: "lib-1", To: "lib-2", Section: "dependencies", Specifier: gitDependency("lib-2")},
+				},
+				[]graph.Edge{
+					{From: "api", To: "lib-2", Section: "devDependencies", Specifier: "^1.0.0"},
+				},
+			))
+		})
+
+		It("Should not return paths between unrelated projects", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.Paths("app", "lib-1")).To(BeEmpty())
+		})
+	})
+
+	Describe("Detecting cycles", func() {
+		It("Should not find cycles in an acyclic graph", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.Cycles()).To(BeEmpty())
+		})
+
+		It("Should find projects that depend on each other", func() {
+			// Given lib-2 also depends on api
+			writePackageJSON("lib-2", "@test-org/lib-2", map[string]string{"api": gitDependency("api")}, nil)
+
+			// When I build the graph
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Then the cycle is detected
+			Expect(g.Cycles()).To(Equal([][]string{{"api", "lib-1", "lib-2"}}))
+
+			// And dependents are still calculated
+			Expect(g.TransitiveDependents("api", 0)).To(Equal([]string{"app", "lib-1", "lib-2"}))
+		})
+	})
+
+	Describe("Ordering projects", func() {
+		It("Should group projects into waves after their dependencies", func() {
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.Waves()).To(Equal([][]string{{"lib-2"}, {"app", "lib-1"}, {"api"}}))
+		})
+
+		It("Should put projects in or depending on a cycle in the last wave", func() {
+			// Given lib-1 and lib-2 depend on each other, and lib-3 depends on nothing
+			writePackageJSON("lib-2", "@test-org/lib-2", map[string]string{"lib-1": gitDependency("lib-1")}, nil)
+			writePackageJSON("lib-3", "lib-3", nil, nil)
+
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(g.Waves()).To(Equal([][]string{{"lib-3"}, {"api", "app", "lib-1", "lib-2"}}))
+		})
+	})
+
+	Describe("Caching the graph", func() {
+		It("Should reuse cached entries for unchanged package.json files", func() {
+			// Given a graph built with a cache
+			_, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			Expect(err).NotTo(HaveOccurred())
+
+			exists, err := afero.Exists(fs, graph.CacheFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			// When a package.json file changes
+			writePackageJSON("app", "app", map[string]string{"lib-1": gitDependency("lib-1")}, nil)
+
+			// Then the graph is rebuilt with the change
+			g, err := graph.Build(fs, graph.BuildOpts{Metarepo: ".", CacheFile: graph.CacheFile})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(g.TransitiveDependents("lib-1", 0)).To(Equal([]string{"api", "app"}))
+			Expect(g.TransitiveDependents("lib-2", 0)).To(Equal([]string{"api", "app", "lib-1"}))
+		})
+	})
+})
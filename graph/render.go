@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Highlights are the sets of projects which are rendered differently
+// from the rest of the metarepo
+type Highlights struct {
+	Story       map[string]bool
+	BlastRadius map[string]bool
+	Artifacts   map[string]bool
+}
+
+type Node struct {
+	Project     string `json:"project" yaml:"project"`
+	Package     string `json:"package" yaml:"package"`
+	Story       bool   `json:"story" yaml:"story"`
+	BlastRadius bool   `json:"blastRadius" yaml:"blastRadius"`
+	Artifact    bool   `json:"artifact" yaml:"artifact"`
+}
+
+// Edges returns every edge in the graph, ordered by dependent project
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, project := range g.Projects {
+		edges = append(edges, g.dependencies[project]...)
+	}
+
+	return edges
+}
+
+// Subgraph returns a graph containing only the given projects and the edges between them
+func (g *Graph) Subgraph(projects map[string]bool) *Graph {
+	sub := &Graph{
+		Packages:     make(map[string]string),
+		dependencies: make(map[string][]Edge),
+		dependents:   make(map[string][]Edge),
+	}
+
+	for _, project := range g.Projects {
+		if !projects[project] {
+			continue
+		}
+
+		sub.Projects = append(sub.Projects, project)
+		sub.Packages[project] = g.Packages[project]
+
+		for _, e := range g.dependencies[project] {
+			if projects[e.To] {
+				sub.dependencies[e.From] = append(sub.dependencies[e.From], e)
+				sub.dependents[e.To] = append(sub.dependents[e.To], e)
+			}
+		}
+	}
+
+	return sub
+}
+
+func (g *Graph) Nodes(h Highlights) []Node {
+	var nodes []Node
+	for _, project := range g.Projects {
+		nodes = append(nodes, Node{
+			Project:     project,
+			Package:     g.Packages[project],
+			Story:       h.Story[project],
+			BlastRadius: h.BlastRadius[project],
+			Artifact:    h.Artifacts[project],
+		})
+	}
+
+	return nodes
+}
+
+// Document is the graph as the nodes and edges written by WriteJSON
+type Document struct {
+	Nodes []Node `json:"nodes" yaml:"nodes"`
+	Edges []Edge `json:"edges" yaml:"edges"`
+}
+
+func (g *Graph) Document(h Highlights) Document {
+	return Document{Nodes: g.Nodes(h), Edges: g.Edges()}
+}
+
+func (g *Graph) WriteJSON(w io.Writer, h Highlights) error {
+	b, err := json.MarshalIndent(g.Document(h), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func (g *Graph) WriteDOT(w io.Writer, h Highlights) error {
+	var b strings.Builder
+	b.WriteString("digraph metarepo {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=ellipse];\n")
+
+	for _, n := range g.Nodes(h) {
+		var attributes []string
+
+		// Story projects take precedence over the blast radius when filling
+		switch {
+		case n.Story:
+			attributes = append(attributes, `style=filled`, `fillcolor="gold"`)
+		case n.BlastRadius:
+			attributes = append(attributes, `style=filled`, `fillcolor="lightsalmon"`)
+		}
+
+		if n.Artifact {
+			attributes = append(attributes, `shape=box`, `peripheries=2`)
+		}
+
+		if len(attributes) > 0 {
+			fmt.Fprintf(&b, "  %q [%s];\n", n.Project, strings.Join(attributes, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %q;\n", n.Project)
+		}
+	}
+
+	for _, e := range g.Edges() {
+		style := ""
+		if e.Section == DevDependencies {
+			style = ", style=dashed"
+		}
+
+		fmt.Fprintf(&b, "  %q -> %q [label=%q%s];\n", e.From, e.To, e.Section, style)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func mermaidID(project string) string {
+	return mermaidUnsafe.ReplaceAllString(project, "_")
+}
+
+func (g *Graph) WriteMermaid(w io.Writer, h Highlights) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	var story, blastRadius, artifacts []string
+	for _, n := range g.Nodes(h) {
+		id := mermaidID(n.Project)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, n.Project)
+
+		switch {
+		case n.Story:
+			story = append(story, id)
+		case n.BlastRadius:
+			blastRadius = append(blastRadius, id)
+		}
+
+		if n.Artifact {
+			artifacts = append(artifacts, id)
+		}
+	}
+
+	for _, e := range g.Edges() {
+		arrow := "-->"
+		if e.Section == DevDependencies {
+			arrow = "-.->"
+		}
+
+		fmt.Fprintf(&b, "  %s %s %s\n", mermaidID(e.From), arrow, mermaidID(e.To))
+	}
+
+	b.WriteString("  classDef story fill:#ffd700;\n")
+	b.WriteString("  classDef blastRadius fill:#ffa07a;\n")
+	b.WriteString("  classDef artifact stroke-width:4px;\n")
+
+	for _, class := range []struct {
+		name string
+		ids  []string
+	}{{"story", story}, {"blastRadius", blastRadius}, {"artifact", artifacts}} {
+		if len(class.ids) > 0 {
+			fmt.Fprintf(&b, "  class %s %s;\n", strings.Join(class.ids, ","), class.name)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
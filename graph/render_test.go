@@ -0,0 +1,77 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/LGUG2Z/story/graph"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Render", func() {
+	var g *graph.Graph
+	var h graph.Highlights
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+
+		// Given a metarepo where api depends on lib-1 and app depends on lib-1 as a dev dependency
+		writePackageJSON("lib-1", "@test-org/lib-1", nil, nil)
+		writePackageJSON("api", "api", map[string]string{"@test-org/lib-1": gitDependency("lib-1")}, nil)
+		writePackageJSON("app", "app", nil, map[string]string{"@test-org/lib-1": gitDependency("lib-1")})
+
+		var err error
+		g, err = graph.Build(fs, graph.BuildOpts{Metarepo: "."})
+		Expect(err).NotTo(HaveOccurred())
+
+		// And a story working on lib-1 with api as an artifact
+		h = graph.Highlights{
+			Story:       map[string]bool{"lib-1": true},
+			BlastRadius: map[string]bool{"api": true, "app": true},
+			Artifacts:   map[string]bool{"api": true},
+		}
+	})
+
+	It("Should render the graph as DOT", func() {
+		var b bytes.Buffer
+		Expect(g.WriteDOT(&b, h)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring(`"lib-1" [style=filled, fillcolor="gold"];`))
+		Expect(b.String()).To(ContainSubstring(`"api" [style=filled, fillcolor="lightsalmon", shape=box, peripheries=2];`))
+		Expect(b.String()).To(ContainSubstring(`"app" -> "lib-1" [label="devDependencies", style=dashed];`))
+	})
+
+	It("Should render the graph as Mermaid", func() {
+		var b bytes.Buffer
+		Expect(g.WriteMermaid(&b, h)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring("lib_1[\"lib-1\"]"))
+		Expect(b.String()).To(ContainSubstring("api --> lib_1"))
+		Expect(b.String()).To(ContainSubstring("app -.-> lib_1"))
+		Expect(b.String()).To(ContainSubstring("class api,app blastRadius;"))
+	})
+
+	It("Should render the graph as JSON", func() {
+		var b bytes.Buffer
+		Expect(g.WriteJSON(&b, h)).To(Succeed())
+
+		var actual struct {
+			Nodes []graph.Node `json:"nodes"`
+			Edges []graph.Edge `json:"edges"`
+		}
+
+		Expect(json.Unmarshal(b.Bytes(), &actual)).To(Succeed())
+		Expect(actual.Nodes).To(ContainElement(graph.Node{Project: "api", Package: "api", BlastRadius: true, Artifact: true}))
+		Expect(actual.Edges).To(HaveLen(2))
+	})
+
+	It("Should only render the projects in a subgraph", func() {
+		var b bytes.Buffer
+		Expect(g.Subgraph(map[string]bool{"api": true, "lib-1": true}).WriteDOT(&b, h)).To(Succeed())
+
+		Expect(b.String()).NotTo(ContainSubstring(`"app"`))
+		Expect(b.String()).To(ContainSubstring(`"api" -> "lib-1"`))
+	})
+})
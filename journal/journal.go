@@ -0,0 +1,356 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LGUG2Z/story/ecosystem"
+	"github.com/LGUG2Z/story/git"
+	"github.com/spf13/afero"
+)
+
+// Directory is where journal entries are kept, relative to the metarepo
+const Directory = ".git/story/journal"
+
+// Metarepo is the key of the metarepo in the projects of an entry
+const Metarepo = "."
+
+// idFormat sorts entries in the order they were made
+const idFormat = "20060102T150405.000000000Z"
+
+// State is the checked out branch and commit of a repository and the heads of its local
+// branches. Branch is empty when the commit is checked out as a detached HEAD.
+type State struct {
+	Branch   string            `json:"branch,omitempty"`
+	Head     string            `json:"head"`
+	Branches map[string]string `json:"branches,omitempty"`
+}
+
+// Change is the state of a repository before and after a command
+type Change struct {
+	Before State `json:"before"`
+	After  State `json:"after"`
+}
+
+// Entry records how a command changed the metarepo and its projects. Files holds the
+// contents of every changed file from before the command, which is nil for new files.
+// Cloned holds the state of the projects which were cloned by the command.
+type Entry struct {
+	ID       string             `json:"id"`
+	Command  []string           `json:"command"`
+	Time     time.Time          `json:"time"`
+	Projects map[string]*Change `json:"projects,omitempty"`
+	Files    map[string]*string `json:"files,omitempty"`
+	Cloned   map[string]*State  `json:"cloned,omitempty"`
+}
+
+// Recorder records an entry for a command from the state before and after it runs
+type Recorder struct {
+	fs     afero.Fs
+	entry  *Entry
+	cloned []string
+}
+
+// Begin records the state of the metarepo and the cloned projects in its .meta file, along
+// with the dependency manifests of those projects
+func Begin(fs afero.Fs, command []string) (*Recorder, error) {
+	projects, err := clonedProjects(fs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Projects outside of a story can be cloned too, and are looked for when the command ends
+	cloned, err := clonedProjects(fs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	r := &Recorder{
+		fs:     fs,
+		cloned: cloned,
+		entry:  &Entry{ID: now.Format(idFormat), Command: command, Time: now, Projects: make(map[string]*Change), Files: make(map[string]*string)},
+	}
+
+	files := []string{".meta"}
+	for _, project := range projects {
+		// Projects without a supported manifest only have their branches recorded
+		a, err := ecosystem.Detect(fs, project)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range a.Files() {
+			files = append(files, filepath.Join(project, file))
+		}
+	}
+
+	for _, project := range append([]string{Metarepo}, projects...) {
+		state, err := snapshot(fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if state != nil {
+			r.entry.Projects[project] = &Change{Before: *state}
+		}
+	}
+
+	for _, file := range files {
+		contents, err := readFile(fs, file)
+		if err != nil {
+			return nil, err
+		}
+
+		r.entry.Files[file] = contents
+	}
+
+	return r, nil
+}
+
+// End records the state after the command and writes an entry if anything changed,
+// returning nil if nothing did
+func (r *Recorder) End() (*Entry, error) {
+	for project, change := range r.entry.Projects {
+		state, err := snapshot(r.fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == nil || reflect.DeepEqual(*state, change.Before) {
+			delete(r.entry.Projects, project)
+			continue
+		}
+
+		change.After = *state
+	}
+
+	for file, before := range r.entry.Files {
+		after, err := readFile(r.fs, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if reflect.DeepEqual(before, after) {
+			delete(r.entry.Files, file)
+		}
+	}
+
+	projects, err := clonedProjects(r.fs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	r.entry.Cloned = make(map[string]*State)
+	for _, project := range projects {
+		if contains(r.cloned, project) {
+			continue
+		}
+
+		state, err := snapshot(r.fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if state != nil {
+			r.entry.Cloned[project] = state
+		}
+	}
+
+	if len(r.entry.Projects) == 0 && len(r.entry.Files) == 0 && len(r.entry.Cloned) == 0 {
+		return nil, nil
+	}
+
+	return r.entry, Write(r.fs, r.entry)
+}
+
+// Affected returns the sorted repositories and files changed by an entry
+func (e *Entry) Affected() []string {
+	var affected []string
+	for project := range e.Projects {
+		affected = append(affected, project)
+	}
+
+	for file := range e.Files {
+		affected = append(affected, file)
+	}
+
+	for project := range e.Cloned {
+		affected = append(affected, project)
+	}
+
+	sort.Strings(affected)
+
+	return affected
+}
+
+// Write writes an entry to the journal
+func Write(fs afero.Fs, entry *Entry) error {
+	if err := fs.MkdirAll(Directory, os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, filepath.Join(Directory, fmt.Sprintf("%s.json", entry.ID)), b, os.FileMode(0666))
+}
+
+// Remove removes an entry from the journal
+func Remove(fs afero.Fs, entry *Entry) error {
+	return fs.Remove(filepath.Join(Directory, fmt.Sprintf("%s.json", entry.ID)))
+}
+
+// List returns the entries in the journal, newest first
+func List(fs afero.Fs) ([]*Entry, error) {
+	exists, err := afero.DirExists(fs, Directory)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	infos, err := afero.ReadDir(fs, Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+
+		b, err := afero.ReadFile(fs, filepath.Join(Directory, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &Entry{}
+		if err := json.Unmarshal(b, entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+
+	return entries, nil
+}
+
+// Current returns the checked out branch and commit of a repository, or nil if it is not
+// a repository with any commits
+func Current(fs afero.Fs, project string) (*State, error) {
+	exists, err := afero.Exists(fs, filepath.Join(project, ".git", "HEAD"))
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	head, err := git.HeadCommit(project)
+	if err != nil {
+		return nil, nil
+	}
+
+	branch, err := git.GetCurrentBranch(fs, project)
+	if err != nil {
+		return nil, err
+	}
+
+	// A detached HEAD holds the commit instead of a branch
+	if branch == head {
+		branch = ""
+	}
+
+	return &State{Branch: branch, Head: head}, nil
+}
+
+func snapshot(fs afero.Fs, project string) (*State, error) {
+	state, err := Current(fs, project)
+	if err != nil || state == nil {
+		return nil, err
+	}
+
+	if state.Branches, err = git.Branches(project); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// clonedProjects returns the sorted projects in a .meta file which have been cloned. On a
+// story these are the projects of the story, as commands don't change other projects,
+// unless all is set.
+func clonedProjects(fs afero.Fs, all bool) ([]string, error) {
+	b, err := afero.ReadFile(fs, ".meta")
+	if err != nil {
+		return nil, err
+	}
+
+	var meta struct {
+		Projects    map[string]string `json:"projects"`
+		AllProjects map[string]string `json:"allProjects"`
+	}
+
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, err
+	}
+
+	if all {
+		if meta.Projects == nil {
+			meta.Projects = make(map[string]string)
+		}
+
+		for project, repository := range meta.AllProjects {
+			meta.Projects[project] = repository
+		}
+	}
+
+	var projects []string
+	for project := range meta.Projects {
+		exists, err := afero.DirExists(fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			projects = append(projects, project)
+		}
+	}
+
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+func contains(projects []string, project string) bool {
+	for _, p := range projects {
+		if p == project {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readFile(fs afero.Fs, name string) (*string, error) {
+	b, err := afero.ReadFile(fs, name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	contents := string(b)
+	return &contents, nil
+}
@@ -0,0 +1,81 @@
+package journal_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/LGUG2Z/story/journal"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Journal", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("Should list entries newest first", func() {
+		first := &journal.Entry{ID: "20191019T120000.000000000Z", Command: []string{"create", "sso-login"}, Time: time.Date(2019, 10, 19, 12, 0, 0, 0, time.UTC)}
+		second := &journal.Entry{ID: "20191019T130000.000000000Z", Command: []string{"add", "api"}, Time: time.Date(2019, 10, 19, 13, 0, 0, 0, time.UTC)}
+
+		Expect(journal.Write(fs, first)).To(Succeed())
+		Expect(journal.Write(fs, second)).To(Succeed())
+
+		entries, err := journal.List(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]*journal.Entry{second, first}))
+
+		Expect(journal.Remove(fs, second)).To(Succeed())
+		Expect(journal.List(fs)).To(Equal([]*journal.Entry{first}))
+	})
+
+	It("Should have no entries before anything is journaled", func() {
+		Expect(journal.List(fs)).To(BeEmpty())
+	})
+
+	It("Should record the dependency manifests of the projects in the story", func() {
+		// Given a story with api and lib, where web is only in the metarepo
+		meta := `{"projects":{"api":"git@github.com:test-org/api.git","lib":"git@github.com:test-org/lib.git"},"allProjects":{"web":"git@github.com:test-org/web.git"}}`
+		Expect(afero.WriteFile(fs, ".meta", []byte(meta), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "api/go.mod", []byte("module github.com/test-org/api\n"), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "lib/package.json", []byte(`{"name": "lib"}`), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "web/package.json", []byte(`{"name": "web"}`), os.FileMode(0666))).To(Succeed())
+
+		// When a command changes the manifests of api and web
+		r, err := journal.Begin(fs, []string{"bump-dep", "lib"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(afero.WriteFile(fs, "api/go.mod", []byte("module github.com/test-org/api\n\ngo 1.12\n"), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "web/package.json", []byte(`{"name": "web", "version": "1.0.0"}`), os.FileMode(0666))).To(Succeed())
+
+		entry, err := r.End()
+		Expect(err).NotTo(HaveOccurred())
+
+		// Then only the go.mod of the story project api is recorded
+		before := "module github.com/test-org/api\n"
+		Expect(entry.Files).To(Equal(map[string]*string{"api/go.mod": &before}))
+	})
+
+	It("Should restore changed files and remove created files", func() {
+		meta := `{"projects":{"api":"git@github.com:test-org/api.git"}}`
+		Expect(afero.WriteFile(fs, ".meta", []byte(`{"story":"sso-login"}`), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, "api/package.json", []byte(`{}`), os.FileMode(0666))).To(Succeed())
+
+		entry := &journal.Entry{Files: map[string]*string{".meta": &meta, "api/package.json": nil}}
+		Expect(journal.RestoreFiles(fs, entry)).To(Succeed())
+
+		Expect(afero.ReadFile(fs, ".meta")).To(Equal([]byte(meta)))
+		Expect(afero.Exists(fs, "api/package.json")).To(BeFalse())
+	})
+
+	It("Should list the repositories and files affected by an entry", func() {
+		entry := &journal.Entry{
+			Projects: map[string]*journal.Change{"api": {}, journal.Metarepo: {}},
+			Files:    map[string]*string{".meta": nil},
+		}
+
+		Expect(entry.Affected()).To(Equal([]string{".", ".meta", "api"}))
+	})
+})
@@ -0,0 +1,185 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/git"
+	"github.com/spf13/afero"
+)
+
+// Outdated returns the sorted repositories which have changed since an entry was recorded
+func Outdated(fs afero.Fs, entry *Entry) ([]string, error) {
+	var outdated []string
+	for project, change := range entry.Projects {
+		state, err := Current(fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == nil || state.Branch != change.After.Branch || state.Head != change.After.Head {
+			outdated = append(outdated, project)
+		}
+	}
+
+	for project, after := range entry.Cloned {
+		state, err := Current(fs, project)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == nil {
+			continue
+		}
+
+		changed, err := git.HasChanges(project)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed || state.Branch != after.Branch || state.Head != after.Head {
+			outdated = append(outdated, project)
+		}
+	}
+
+	sort.Strings(outdated)
+
+	return outdated, nil
+}
+
+// Revert checks out the branch or commit of a repository from before an entry was
+// recorded, moves branches back to where they were and deletes branches which were created
+func Revert(entry *Entry, project string) (string, error) {
+	change, exists := entry.Projects[project]
+	if !exists {
+		return "", nil
+	}
+
+	before, after := change.Before, change.After
+
+	var outputs []string
+	if before.Branch != after.Branch || before.Head != after.Head {
+		var output string
+		var err error
+		if before.Branch == "" {
+			output, err = git.CheckoutBranch(git.CheckoutBranchOpts{Branch: before.Head, Project: project})
+		} else {
+			output, err = git.SetBranch(git.SetBranchOpts{Project: project, Branch: before.Branch, Commit: before.Head, Checkout: true})
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		outputs = append(outputs, output)
+	}
+
+	var branches []string
+	for branch := range before.Branches {
+		branches = append(branches, branch)
+	}
+
+	for branch := range after.Branches {
+		if _, existed := before.Branches[branch]; !existed {
+			branches = append(branches, branch)
+		}
+	}
+
+	sort.Strings(branches)
+
+	for _, branch := range branches {
+		// The checked out branch has already been moved back
+		if branch == before.Branch || before.Branches[branch] == after.Branches[branch] {
+			continue
+		}
+
+		// Branches which were created are deleted, as they have no commit to go back to
+		output, err := git.SetBranch(git.SetBranchOpts{Project: project, Branch: branch, Commit: before.Branches[branch]})
+		if err != nil {
+			return "", err
+		}
+
+		outputs = append(outputs, output)
+	}
+
+	return strings.TrimSpace(strings.Join(outputs, "\n")), nil
+}
+
+// DiscardFiles discards the changes to the files of an entry in repositories which are
+// checked out by Revert, so that the changes can't stop the checkout. The files are
+// written back by RestoreFiles.
+func DiscardFiles(fs afero.Fs, entry *Entry) error {
+	files := make(map[string][]string)
+	for file := range entry.Files {
+		project, name := Metarepo, file
+		if parts := strings.SplitN(file, string(filepath.Separator), 2); len(parts) == 2 {
+			project, name = parts[0], parts[1]
+		}
+
+		change, exists := entry.Projects[project]
+		if !exists || (change.Before.Branch == change.After.Branch && change.Before.Head == change.After.Head) {
+			continue
+		}
+
+		files[project] = append(files[project], name)
+	}
+
+	for project, names := range files {
+		sort.Strings(names)
+
+		untracked, err := git.DiscardChanges(git.DiscardChangesOpts{Project: project, Files: names})
+		if err != nil {
+			return err
+		}
+
+		// Nothing is checked out in a dry run, so the files don't need to be out of the way
+		if dryrun.Enabled() {
+			continue
+		}
+
+		for _, name := range untracked {
+			if err := fs.Remove(filepath.Join(project, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveCloned removes a project which was cloned by the command of an entry
+func RemoveCloned(fs afero.Fs, entry *Entry, project string) error {
+	if _, exists := entry.Cloned[project]; !exists {
+		return nil
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Record(project, "rm", "-r", project)
+		return nil
+	}
+
+	return fs.RemoveAll(project)
+}
+
+// RestoreFiles writes back the contents of the files changed by an entry, and removes
+// files which were created
+func RestoreFiles(fs afero.Fs, entry *Entry) error {
+	for file, contents := range entry.Files {
+		if contents == nil {
+			if err := fs.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			continue
+		}
+
+		if err := afero.WriteFile(fs, file, []byte(*contents), os.FileMode(0666)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
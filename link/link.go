@@ -0,0 +1,131 @@
+package link
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// BackupDirectory is where installed copies of linked packages are moved to, relative
+// to the node_modules directory of a project
+const BackupDirectory = ".story-unlinked"
+
+// Link is a symlink from the node_modules directory of a project to the checkout of
+// another metarepo project which provides one of its dependencies
+type Link struct {
+	Project string
+	Package string
+	Target  string
+}
+
+// Path is the location of the symlink
+func (l Link) Path() string {
+	return filepath.Join(l.Project, "node_modules", filepath.FromSlash(l.Package))
+}
+
+func (l Link) backup() string {
+	return filepath.Join(l.Project, "node_modules", BackupDirectory, filepath.FromSlash(l.Package))
+}
+
+// Symlinks are created with the os package directly as afero does not support them
+func isSymlink(path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return info.Mode()&os.ModeSymlink != 0, nil
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Create replaces the installed copy of a package with a symlink to the target project,
+// moving the installed copy aside so that it can be restored. It returns false if the
+// link already exists.
+func Create(l Link) (bool, error) {
+	target, err := filepath.Rel(filepath.Dir(l.Path()), l.Target)
+	if err != nil {
+		return false, err
+	}
+
+	symlink, err := isSymlink(l.Path())
+	if err != nil {
+		return false, err
+	}
+
+	if symlink {
+		current, err := os.Readlink(l.Path())
+		if err != nil {
+			return false, err
+		}
+
+		if current == target {
+			return false, nil
+		}
+
+		if err := os.Remove(l.Path()); err != nil {
+			return false, err
+		}
+	} else {
+		installed, err := exists(l.Path())
+		if err != nil {
+			return false, err
+		}
+
+		if installed {
+			if err := os.RemoveAll(l.backup()); err != nil {
+				return false, err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(l.backup()), os.FileMode(0700)); err != nil {
+				return false, err
+			}
+
+			if err := os.Rename(l.Path(), l.backup()); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.Path()), os.FileMode(0700)); err != nil {
+		return false, err
+	}
+
+	return true, os.Symlink(target, l.Path())
+}
+
+// Remove deletes the symlink for a package and restores its installed copy if there
+// was one. It returns false if the package is not linked.
+func Remove(l Link) (bool, error) {
+	symlink, err := isSymlink(l.Path())
+	if err != nil || !symlink {
+		return false, err
+	}
+
+	if err := os.Remove(l.Path()); err != nil {
+		return false, err
+	}
+
+	backedUp, err := exists(l.backup())
+	if err != nil {
+		return false, err
+	}
+
+	if backedUp {
+		if err := os.Rename(l.backup(), l.Path()); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
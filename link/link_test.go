@@ -0,0 +1,78 @@
+package link_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/LGUG2Z/story/link"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Link", func() {
+	var directory, wd string
+
+	BeforeEach(func() {
+		var err error
+		wd, err = os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+
+		directory, err = ioutil.TempDir("", "link")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chdir(directory)).To(Succeed())
+
+		// Given an app with an installed copy of a scoped library which is also checked out
+		Expect(os.MkdirAll("app/node_modules/@test-org/lib", os.FileMode(0700))).To(Succeed())
+		Expect(ioutil.WriteFile("app/node_modules/@test-org/lib/index.js", []byte("installed"), os.FileMode(0666))).To(Succeed())
+		Expect(os.MkdirAll("lib", os.FileMode(0700))).To(Succeed())
+		Expect(ioutil.WriteFile("lib/index.js", []byte("checkout"), os.FileMode(0666))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(wd)).To(Succeed())
+		Expect(os.RemoveAll(directory)).To(Succeed())
+	})
+
+	l := link.Link{Project: "app", Package: "@test-org/lib", Target: "lib"}
+
+	It("Should link the checkout in place of the installed copy", func() {
+		// When I link the library
+		created, err := link.Create(l)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		// Then the checkout is used through a relative symlink
+		target, err := os.Readlink("app/node_modules/@test-org/lib")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).To(Equal("../../../lib"))
+
+		b, err := ioutil.ReadFile("app/node_modules/@test-org/lib/index.js")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("checkout"))
+
+		// And linking again does nothing
+		created, err = link.Create(l)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeFalse())
+	})
+
+	It("Should restore the installed copy when unlinking", func() {
+		_, err := link.Create(l)
+		Expect(err).NotTo(HaveOccurred())
+
+		// When I unlink the library
+		removed, err := link.Remove(l)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(BeTrue())
+
+		// Then the installed copy is back
+		b, err := ioutil.ReadFile("app/node_modules/@test-org/lib/index.js")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("installed"))
+
+		// And unlinking again does nothing
+		removed, err = link.Remove(l)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(BeFalse())
+	})
+})
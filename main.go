@@ -9,6 +9,7 @@ import (
 
 func main() {
 	if err := cli.App().Run(os.Args); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(cli.ExitCode(err))
 	}
 }
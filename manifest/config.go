@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/afero"
+)
+
+// ConfigFile is an optional file in the root of the metarepo which configures the
+// commands story runs in projects
+const ConfigFile = ".storyconfig"
+
+// Commands are shell commands run in the directory of a project
+type Commands struct {
+	Test  string `json:"test,omitempty"`
+	Build string `json:"build,omitempty"`
+}
+
+type Config struct {
+	// Defaults are used for projects which do not configure their own commands
+	Defaults Commands            `json:"defaults,omitempty"`
+	Projects map[string]Commands `json:"projects,omitempty"`
+}
+
+// LoadConfig loads the .storyconfig file, returning an empty config if there isn't one
+func LoadConfig(fs afero.Fs) (*Config, error) {
+	c := &Config{}
+
+	exists, err := afero.Exists(fs, ConfigFile)
+	if err != nil || !exists {
+		return c, err
+	}
+
+	bytes, err := afero.ReadFile(fs, ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bytes, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) command(project string, fallback string, choose func(Commands) string) []string {
+	command := choose(c.Projects[project])
+	if command == "" {
+		command = choose(c.Defaults)
+	}
+
+	if command == "" {
+		command = fallback
+	}
+
+	return []string{"sh", "-c", command}
+}
+
+// TestCommand is the command which runs the tests of a project, which is npm test by default
+func (c *Config) TestCommand(project string) []string {
+	return c.command(project, "npm test", func(commands Commands) string { return commands.Test })
+}
+
+// BuildCommand is the command which builds an artifact, which is npm run build by default
+func (c *Config) BuildCommand(project string) []string {
+	return c.command(project, "npm run build", func(commands Commands) string { return commands.Build })
+}
@@ -0,0 +1,51 @@
+package manifest
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// FindMetarepo returns the nearest directory containing both a .meta file and a .git
+// directory, starting from an absolute path and walking up to the filesystem root, or an
+// empty string if there isn't one
+func FindMetarepo(fs afero.Fs, dir string) (string, error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		meta, err := afero.Exists(fs, filepath.Join(current, ".meta"))
+		if err != nil {
+			return "", err
+		}
+
+		repository, err := afero.DirExists(fs, filepath.Join(current, ".git"))
+		if err != nil {
+			return "", err
+		}
+
+		if meta && repository {
+			return current, nil
+		}
+
+		if filepath.Dir(current) == current {
+			return "", nil
+		}
+	}
+}
+
+// ProjectAt returns the project of the metarepo which contains an absolute path, or an
+// empty string if the path is not inside a project
+func ProjectAt(fs afero.Fs, metarepo, dir string) (string, error) {
+	rel, err := filepath.Rel(metarepo, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", err
+	}
+
+	project := strings.Split(filepath.ToSlash(rel), "/")[0]
+
+	repository, err := afero.Exists(fs, filepath.Join(metarepo, project, ".git"))
+	if err != nil || !repository {
+		return "", err
+	}
+
+	return project, nil
+}
@@ -0,0 +1,40 @@
+package manifest_test
+
+import (
+	"os"
+
+	"github.com/LGUG2Z/story/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Root", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		// Given a metarepo with a cloned project
+		fs = afero.NewMemMapFs()
+		Expect(fs.MkdirAll("/work/metarepo/.git", os.FileMode(0700))).To(Succeed())
+		Expect(afero.WriteFile(fs, "/work/metarepo/.meta", []byte("{}"), os.FileMode(0666))).To(Succeed())
+		Expect(fs.MkdirAll("/work/metarepo/api/.git", os.FileMode(0700))).To(Succeed())
+		Expect(fs.MkdirAll("/work/metarepo/api/src/routes", os.FileMode(0700))).To(Succeed())
+		Expect(fs.MkdirAll("/work/metarepo/story", os.FileMode(0700))).To(Succeed())
+	})
+
+	It("Should find the metarepo from inside a project", func() {
+		Expect(manifest.FindMetarepo(fs, "/work/metarepo/api/src/routes")).To(Equal("/work/metarepo"))
+		Expect(manifest.FindMetarepo(fs, "/work/metarepo")).To(Equal("/work/metarepo"))
+	})
+
+	It("Should not find a metarepo outside of one", func() {
+		Expect(manifest.FindMetarepo(fs, "/work")).To(BeEmpty())
+	})
+
+	It("Should find the project containing a directory", func() {
+		Expect(manifest.ProjectAt(fs, "/work/metarepo", "/work/metarepo/api/src/routes")).To(Equal("api"))
+		Expect(manifest.ProjectAt(fs, "/work/metarepo", "/work/metarepo/api")).To(Equal("api"))
+		Expect(manifest.ProjectAt(fs, "/work/metarepo", "/work/metarepo/story")).To(BeEmpty())
+		Expect(manifest.ProjectAt(fs, "/work/metarepo", "/work/metarepo")).To(BeEmpty())
+	})
+})
@@ -9,18 +9,34 @@ import (
 
 	"sort"
 
-	"github.com/LGUG2Z/blastradius/blastradius"
 	"github.com/spf13/afero"
 )
 
 type Story struct {
-	Name          string              `json:"story,omitempty"`
-	Orgranisation string              `json:"organisation"`
-	Projects      map[string]string   `json:"projects,omitempty"`
-	Hashes        map[string]string   `json:"hashes,omitempty"`
-	BlastRadius   map[string][]string `json:"blastRadius,omitempty"`
-	Artifacts     map[string]bool     `json:"artifacts,omitempty"`
-	AllProjects   map[string]string   `json:"allProjects"`
+	Name          string                       `json:"story,omitempty"`
+	Orgranisation string                       `json:"organisation"`
+	Projects      map[string]string            `json:"projects,omitempty"`
+	Hashes        map[string]string            `json:"hashes,omitempty"`
+	BlastRadius   map[string][]string          `json:"blastRadius,omitempty"`
+	Artifacts     map[string]bool              `json:"artifacts,omitempty"`
+	AllProjects   map[string]string            `json:"allProjects"`
+	Prerelease    *Prerelease                  `json:"prerelease,omitempty"`
+	Releases      map[string]string            `json:"releases,omitempty"`
+	Tags          map[string]string            `json:"tags,omitempty"`
+	Originals     map[string]map[string]string `json:"originals,omitempty"`
+	Depth         int                          `json:"depth,omitempty"`
+}
+
+// Prerelease records the versions of story projects published to an npm registry
+// and the original ranges of the dependencies that were rewritten to use them
+type Prerelease struct {
+	Registry string                       `json:"registry"`
+	Versions map[string]string            `json:"versions"`
+	Ranges   map[string]map[string]string `json:"ranges"`
+}
+
+type RadiusCalculator interface {
+	Calculate(fs afero.Fs, metarepo, project string) ([]string, error)
 }
 
 func NewStory(name string, meta *Meta) *Story {
@@ -107,7 +123,12 @@ func (s *Story) GetCommitHashes(fs afero.Fs) (map[string]string, error) {
 	return hashMap, nil
 }
 
-func (s *Story) CalculateBlastRadiusForProject(fs afero.Fs, blaster blastradius.RadiusCalculator, project string) error {
+// TagPrefix is the prefix of the tags that projects are pinned to for a story
+func (s *Story) TagPrefix() string {
+	return fmt.Sprintf("story/%s/", s.Name)
+}
+
+func (s *Story) CalculateBlastRadiusForProject(fs afero.Fs, blaster RadiusCalculator, project string) error {
 	if s.BlastRadius == nil {
 		s.BlastRadius = make(map[string][]string)
 	}
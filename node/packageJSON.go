@@ -16,6 +16,7 @@ import (
 
 type PackageJSON struct {
 	Raw             *orderedmap.OrderedMap
+	Name            string
 	Dependencies    map[string]string
 	DevDependencies map[string]string
 }
@@ -31,38 +32,70 @@ func (p *PackageJSON) Load(fs afero.Fs, project string) error {
 		return err
 	}
 
-	if dependencies, ok := p.Raw.Get("dependencies"); ok {
-		p.Dependencies = make(map[string]string)
-		d := dependencies.(orderedmap.OrderedMap)
-		for _, k := range d.Keys() {
-			if v, ok := d.Get(k); ok {
-				p.Dependencies[k] = v.(string)
-			}
+	if name, ok := p.Raw.Get("name"); ok {
+		if n, ok := name.(string); ok {
+			p.Name = n
 		}
 	}
 
-	if devDependencies, ok := p.Raw.Get("devDependencies"); ok {
-		p.DevDependencies = make(map[string]string)
-		d := devDependencies.(orderedmap.OrderedMap)
-		for _, k := range d.Keys() {
-			if v, ok := d.Get(k); ok {
-				p.DevDependencies[k] = v.(string)
-			}
-		}
+	if p.Dependencies, err = p.section(project, "dependencies"); err != nil {
+		return err
+	}
+
+	if p.DevDependencies, err = p.section(project, "devDependencies"); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// section returns the packages and specifiers in a dependency section, or nil if the
+// package.json file does not have the section
+func (p *PackageJSON) section(project, name string) (map[string]string, error) {
+	value, ok := p.Raw.Get(name)
+	if !ok {
+		return nil, nil
+	}
+
+	d, ok := value.(orderedmap.OrderedMap)
+	if !ok {
+		return nil, fmt.Errorf("%s/package.json: %s is not an object", project, name)
+	}
+
+	dependencies := make(map[string]string)
+	for _, k := range d.Keys() {
+		v, _ := d.Get(k)
+		specifier, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/package.json: %s in %s does not have a version string", project, k, name)
+		}
+
+		dependencies[k] = specifier
+	}
+
+	return dependencies, nil
+}
+
 func (p *PackageJSON) Write(fs afero.Fs, project string) error {
-	dependencies, err := json.Marshal(p.Dependencies)
+	b, err := p.Marshal()
 	if err != nil {
 		return err
 	}
 
+	filename := fmt.Sprintf("%s/package.json", project)
+	return afero.WriteFile(fs, filename, b, os.FileMode(0666))
+}
+
+// Marshal returns the contents of the package.json file as they would be written
+func (p *PackageJSON) Marshal() ([]byte, error) {
+	dependencies, err := json.Marshal(p.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+
 	devDependencies, err := json.Marshal(p.DevDependencies)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if p.Dependencies != nil {
@@ -75,7 +108,7 @@ func (p *PackageJSON) Write(fs afero.Fs, project string) error {
 
 	b, err := json.MarshalIndent(&p.Raw, "", "  ")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	b = bytes.Replace(b, []byte("\\u003c"), []byte("<"), -1)
@@ -83,8 +116,38 @@ func (p *PackageJSON) Write(fs afero.Fs, project string) error {
 	b = bytes.Replace(b, []byte("\\u0026"), []byte("&"), -1)
 	b = append(b, "\n"...)
 
-	filename := fmt.Sprintf("%s/package.json", project)
-	return afero.WriteFile(fs, filename, b, os.FileMode(0666))
+	return b, nil
+}
+
+// Version returns the version of the package, or 0.0.0 if it does not have one
+func (p *PackageJSON) Version() string {
+	if version, ok := p.Raw.Get("version"); ok {
+		if v, ok := version.(string); ok && v != "" {
+			return v
+		}
+	}
+
+	return "0.0.0"
+}
+
+func (p *PackageJSON) SetVersion(version string) {
+	p.Raw.Set("version", version)
+}
+
+// SetDependency sets the specifier of a package in whichever dependency section it is
+// declared, returning the previous specifier and whether the package was found
+func (p *PackageJSON) SetDependency(pkg, specifier string) (string, bool) {
+	if previous, exists := p.Dependencies[pkg]; exists {
+		p.Dependencies[pkg] = specifier
+		return previous, true
+	}
+
+	if previous, exists := p.DevDependencies[pkg]; exists {
+		p.DevDependencies[pkg] = specifier
+		return previous, true
+	}
+
+	return "", false
 }
 
 func (p *PackageJSON) setPrivateDependencyBranchToStory(dependency, story string) {
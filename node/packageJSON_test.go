@@ -80,6 +80,21 @@ var _ = Describe("PackageJSON", func() {
 			// When I load the file then an error is thrown
 			Expect(p.Load(fs, "invalid")).NotTo(Succeed())
 		})
+
+		It("It should return an error for dependency sections which are not objects of strings", func() {
+			// Given projects whose dependencies are an array, and whose specifiers are not strings
+			for project, contents := range map[string]string{
+				"array":  `{"name": "array", "dependencies": ["one"]}`,
+				"number": `{"name": "number", "devDependencies": {"one": 1}}`,
+			} {
+				Expect(fs.MkdirAll(project, os.FileMode(0700))).To(Succeed())
+				Expect(afero.WriteFile(fs, project+"/package.json", []byte(contents), os.FileMode(0600))).To(Succeed())
+			}
+
+			// When I load the files then errors are returned instead of panicking
+			Expect(p.Load(fs, "array")).To(MatchError("array/package.json: dependencies is not an object"))
+			Expect(p.Load(fs, "number")).To(MatchError("number/package.json: one in devDependencies does not have a version string"))
+		})
 	})
 
 	Describe("Writing a file", func() {
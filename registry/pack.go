@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/LGUG2Z/story/bundle"
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/git"
+	"github.com/LGUG2Z/story/node"
+	"github.com/iancoleman/orderedmap"
+	"github.com/spf13/afero"
+)
+
+// Pack creates an npm tarball of a project at a commit with npm pack, replacing its
+// package.json file with the given contents. Only the committed files are packed, and
+// npm decides which of them are included and runs the prepack and prepare scripts. In a
+// dry run the npm commands are recorded instead and no tarball is returned.
+func Pack(project, commit string, packageJSON []byte) ([]byte, error) {
+	archive, err := git.Archive(project, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "story-pack")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	fs := afero.NewOsFs()
+	if err := bundle.Extract(fs, dir, archive); err != nil {
+		return nil, err
+	}
+
+	// Scripts which build the package need its dependencies, which are installed as they
+	// were declared at the commit
+	build, err := hasBuildScript(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	install := []string{"install", "--no-audit", "--no-fund"}
+	if dryrun.Enabled() {
+		if build {
+			dryrun.Record(project, "npm", install...)
+		}
+
+		dryrun.Record(project, "npm", "pack")
+		return nil, nil
+	}
+
+	if build {
+		if _, err := npm(dir, install...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := afero.WriteFile(fs, filepath.Join(dir, "package.json"), packageJSON, os.FileMode(0644)); err != nil {
+		return nil, err
+	}
+
+	output, err := npm(dir, "pack")
+	if err != nil {
+		return nil, err
+	}
+
+	// The name of the tarball is printed last, after the output of any scripts
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return ioutil.ReadFile(filepath.Join(dir, strings.TrimSpace(lines[len(lines)-1])))
+}
+
+func hasBuildScript(fs afero.Fs, dir string) (bool, error) {
+	p := node.PackageJSON{}
+	if err := p.Load(fs, dir); err != nil {
+		return false, err
+	}
+
+	scripts, ok := p.Raw.Get("scripts")
+	if !ok {
+		return false, nil
+	}
+
+	s, ok := scripts.(orderedmap.OrderedMap)
+	if !ok {
+		return false, nil
+	}
+
+	for _, script := range []string{"prepack", "prepare"} {
+		if _, ok := s.Get(script); ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func npm(dir string, args ...string) (string, error) {
+	command := exec.Command("npm", args...)
+	command.Dir = dir
+
+	output, err := command.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("npm %s: %s: %s", strings.Join(args, " "), err, exitError.Stderr)
+		}
+
+		return "", err
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/LGUG2Z/story/node"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Client publishes packages to an npm compatible registry such as Verdaccio
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+func NewClient(registryURL, token string) *Client {
+	return &Client{URL: strings.TrimSuffix(registryURL, "/"), Token: token, HTTP: http.DefaultClient}
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^0-9a-z-]+`)
+
+// Tag is the dist-tag that prerelease versions of a story are published under, so
+// that publishing them never moves the latest tag. The conventional story/ prefix of
+// story names is replaced rather than repeated.
+func Tag(story string) string {
+	story = strings.TrimPrefix(story, "story/")
+	return fmt.Sprintf("story-%s", strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(story), "-"), "-"))
+}
+
+// PrereleaseVersion returns the version a project is published as for a story,
+// such as 1.4.0-story-auth-endpoint.1a2b3c4
+func PrereleaseVersion(version, story, hash string) string {
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+
+	return fmt.Sprintf("%s-%s.%s", version, Tag(story), hash)
+}
+
+// Publish packs a project at a commit and publishes it to the registry as the given version
+func (c *Client) Publish(project, commit string, p *node.PackageJSON, version, tag string) error {
+	b, err := p.Marshal()
+	if err != nil {
+		return err
+	}
+
+	raw := orderedmap.New()
+	if err := raw.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	raw.Set("version", version)
+	packageJSON, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tarball, err := Pack(project, commit, packageJSON)
+	if err != nil {
+		return err
+	}
+
+	name := p.Name
+	if name == "" {
+		name = filepath.Base(project)
+	}
+
+	metadata := make(map[string]interface{})
+	if err := json.Unmarshal(packageJSON, &metadata); err != nil {
+		return err
+	}
+
+	sha1sum := sha1.Sum(tarball)
+	sha512sum := sha512.Sum512(tarball)
+	filename := fmt.Sprintf("%s-%s.tgz", name[strings.LastIndex(name, "/")+1:], version)
+
+	metadata["_id"] = fmt.Sprintf("%s@%s", name, version)
+	metadata["dist"] = map[string]string{
+		"shasum":    hex.EncodeToString(sha1sum[:]),
+		"integrity": fmt.Sprintf("sha512-%s", base64.StdEncoding.EncodeToString(sha512sum[:])),
+		"tarball":   fmt.Sprintf("%s/%s/-/%s", c.URL, name, filename),
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"_id":       name,
+		"name":      name,
+		"dist-tags": map[string]string{tag: version},
+		"versions":  map[string]interface{}{version: metadata},
+		"_attachments": map[string]interface{}{
+			filename: map[string]interface{}{
+				"content_type": "application/octet-stream",
+				"data":         base64.StdEncoding.EncodeToString(tarball),
+				"length":       len(tarball),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s", c.URL, url.PathEscape(name)), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	}
+
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(response.Body)
+		return ErrPublishFailed(name, version, response.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+func ErrPublishFailed(name, version, status, message string) error {
+	return fmt.Errorf("publishing %s@%s failed with %s: %s", name, version, status, message)
+}
@@ -0,0 +1,207 @@
+package registry_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/LGUG2Z/story/dryrun"
+	"github.com/LGUG2Z/story/node"
+	"github.com/LGUG2Z/story/registry"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+type publication struct {
+	Path        string
+	Auth        string
+	DistTags    map[string]string                 `json:"dist-tags"`
+	Versions    map[string]map[string]interface{} `json:"versions"`
+	Attachments map[string]struct{ Data string }  `json:"_attachments"`
+}
+
+// standIn behaves like Verdaccio, refusing to publish the same version twice
+func standIn(publications *[]publication) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Expect(r.Method).To(Equal(http.MethodPut))
+
+		p := publication{Path: r.URL.EscapedPath(), Auth: r.Header.Get("Authorization")}
+		Expect(json.NewDecoder(r.Body).Decode(&p)).To(Succeed())
+
+		for _, published := range *publications {
+			for version := range p.Versions {
+				if _, exists := published.Versions[version]; exists && published.Path == p.Path {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"error":"this package is already present"}`))
+					return
+				}
+			}
+		}
+
+		*publications = append(*publications, p)
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+func unpack(data string) map[string]string {
+	b, err := base64.StdEncoding.DecodeString(data)
+	Expect(err).NotTo(HaveOccurred())
+
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	Expect(err).NotTo(HaveOccurred())
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+
+		contents, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(contents)
+	}
+
+	return files
+}
+
+var _ = Describe("Registry", func() {
+	var fs afero.Fs
+	var lib string
+	var commit string
+	var publications []publication
+	var server *httptest.Server
+
+	run := func(args ...string) string {
+		command := exec.Command("git", args...)
+		command.Dir = lib
+		out, err := command.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	BeforeEach(func() {
+		fs = afero.NewOsFs()
+		publications = nil
+		server = standIn(&publications)
+
+		// Given a library which builds a file before it is packed, and only publishes some files
+		dir, err := ioutil.TempDir("", "registry")
+		Expect(err).NotTo(HaveOccurred())
+		lib = filepath.Join(dir, "lib")
+
+		Expect(fs.MkdirAll(filepath.Join(lib, "node_modules/lodash"), os.FileMode(0700))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, "package.json"), []byte(`{
+  "name": "@test-org/lib",
+  "version": "1.4.0",
+  "files": ["index.js", "built.js"],
+  "scripts": {"prepack": "node -e \"require('fs').writeFileSync('built.js', 'built')\""}
+}`), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, "index.js"), []byte("module.exports = {}\n"), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, "test.js"), []byte(""), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, ".gitignore"), []byte("node_modules\n"), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, "node_modules/lodash/index.js"), []byte(""), os.FileMode(0666))).To(Succeed())
+
+		run("init")
+		run("add", "--all")
+		run("commit", "-m", "initial commit")
+		commit = run("rev-parse", "HEAD")
+
+		// And changes which have not been committed
+		Expect(afero.WriteFile(fs, filepath.Join(lib, "index.js"), []byte("changed"), os.FileMode(0666))).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join(lib, ".env"), []byte("SECRET=1"), os.FileMode(0666))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(filepath.Dir(lib))).To(Succeed())
+	})
+
+	Describe("Naming prerelease versions", func() {
+		It("Should use the story and the short commit hash", func() {
+			Expect(registry.PrereleaseVersion("1.4.0", "auth-endpoint", "1a2b3c4d5e6f")).To(Equal("1.4.0-story-auth-endpoint.1a2b3c4"))
+		})
+
+		It("Should replace an existing prerelease and sanitise the story name", func() {
+			Expect(registry.PrereleaseVersion("1.4.0-beta.1", "feature/Auth_Endpoint", "1a2b3c4")).To(Equal("1.4.0-story-feature-auth-endpoint.1a2b3c4"))
+		})
+
+		It("Should not repeat the story prefix of story names", func() {
+			Expect(registry.Tag("story/auth-endpoint")).To(Equal("story-auth-endpoint"))
+			Expect(registry.PrereleaseVersion("1.4.0", "story/auth-endpoint", "1a2b3c4")).To(Equal("1.4.0-story-auth-endpoint.1a2b3c4"))
+		})
+	})
+
+	Describe("Packing", func() {
+		It("Should only record the npm commands in a dry run", func() {
+			dryrun.Enable(true)
+			defer dryrun.Enable(false)
+
+			tarball, err := registry.Pack(lib, commit, []byte(`{"name": "@test-org/lib", "version": "1.4.0"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tarball).To(BeNil())
+
+			Expect(dryrun.Operations()).To(Equal([]dryrun.Operation{
+				{Project: lib, Description: "npm install --no-audit --no-fund"},
+				{Project: lib, Description: "npm pack"},
+			}))
+		})
+	})
+
+	Describe("Publishing", func() {
+		It("Should publish a tarball of the project with the prerelease version", func() {
+			p := node.PackageJSON{}
+			Expect(p.Load(fs, lib)).To(Succeed())
+
+			// When I publish the project
+			client := registry.NewClient(server.URL, "secret")
+			Expect(client.Publish(lib, commit, &p, "1.4.0-story-test.abc1234", "story-test")).To(Succeed())
+
+			// Then the registry receives the scoped package under the story dist-tag
+			Expect(publications).To(HaveLen(1))
+			Expect(publications[0].Path).To(Equal("/@test-org%2Flib"))
+			Expect(publications[0].Auth).To(Equal("Bearer secret"))
+			Expect(publications[0].DistTags).To(Equal(map[string]string{"story-test": "1.4.0-story-test.abc1234"}))
+			Expect(publications[0].Versions["1.4.0-story-test.abc1234"]).To(HaveKeyWithValue("version", "1.4.0-story-test.abc1234"))
+
+			// And the tarball contains the committed and built files that npm would publish
+			files := unpack(publications[0].Attachments["lib-1.4.0-story-test.abc1234.tgz"].Data)
+			Expect(files).To(HaveKeyWithValue("package/index.js", "module.exports = {}\n"))
+			Expect(files).To(HaveKeyWithValue("package/built.js", "built"))
+			Expect(files).NotTo(HaveKey("package/test.js"))
+			Expect(files).NotTo(HaveKey("package/.env"))
+			Expect(files).NotTo(HaveKey("package/node_modules/lodash/index.js"))
+			Expect(files["package/package.json"]).To(ContainSubstring(`"version": "1.4.0-story-test.abc1234"`))
+
+			// And the project's package.json is not modified
+			b, err := afero.ReadFile(fs, filepath.Join(lib, "package.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(`"version": "1.4.0"`))
+		})
+
+		It("Should return an error when the registry refuses the package", func() {
+			p := node.PackageJSON{}
+			Expect(p.Load(fs, lib)).To(Succeed())
+
+			client := registry.NewClient(server.URL, "")
+			Expect(client.Publish(lib, commit, &p, "1.4.0-story-test.abc1234", "story-test")).To(Succeed())
+
+			// When I publish the same version again
+			err := client.Publish(lib, commit, &p, "1.4.0-story-test.abc1234", "story-test")
+
+			// Then the error from the registry is returned
+			Expect(err).To(MatchError(registry.ErrPublishFailed("@test-org/lib", "1.4.0-story-test.abc1234", "409 Conflict", `{"error":"this package is already present"}`)))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Bump is the semantic versioning increment of a release
+type Bump int
+
+const (
+	None Bump = iota
+	Patch
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+var (
+	conventionalCommit = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?:`)
+	breakingChange     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+	semver             = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)`)
+	semverRange        = regexp.MustCompile(`^(\^|~|>=|=)?\s*v?[0-9]+\.[0-9]+\.[0-9]+\S*$`)
+)
+
+// FromMessages chooses a bump from conventional commit messages: breaking changes
+// are major, features are minor and anything else is a patch
+func FromMessages(messages []string) Bump {
+	bump := None
+	for _, message := range messages {
+		b := Patch
+		if m := conventionalCommit.FindStringSubmatch(message); m != nil {
+			if m[3] == "!" {
+				b = Major
+			} else if strings.ToLower(m[1]) == "feat" {
+				b = Minor
+			}
+		}
+
+		if breakingChange.MatchString(message) {
+			b = Major
+		}
+
+		if b > bump {
+			bump = b
+		}
+	}
+
+	return bump
+}
+
+// Increment applies a bump to a version, dropping any prerelease or build metadata
+func Increment(version string, bump Bump) (string, error) {
+	m := semver.FindStringSubmatch(version)
+	if m == nil {
+		return "", ErrInvalidVersion(version)
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+
+	switch bump {
+	case Major:
+		major, minor, patch = major+1, 0, 0
+	case Minor:
+		minor, patch = minor+1, 0
+	case Patch:
+		patch++
+	}
+
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+}
+
+// Tag is the name of the git tag for a released version
+func Tag(version string) string {
+	return fmt.Sprintf("v%s", version)
+}
+
+// UpdateRange points a dependency specifier at a released version, keeping the range
+// operator of semver ranges and pointing git URLs at the release tag. Specifiers which
+// are neither are returned unchanged.
+func UpdateRange(specifier, version string) (string, bool) {
+	if strings.Contains(specifier, ".git") {
+		return fmt.Sprintf("%s#%s", strings.Split(specifier, "#")[0], Tag(version)), true
+	}
+
+	if m := semverRange.FindStringSubmatch(specifier); m != nil {
+		return fmt.Sprintf("%s%s", m[1], version), true
+	}
+
+	return specifier, false
+}
+
+func ErrInvalidVersion(version string) error {
+	return fmt.Errorf("%s is not a semantic version", version)
+}
@@ -0,0 +1,60 @@
+package release_test
+
+import (
+	"github.com/LGUG2Z/story/release"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func updated(specifier, version string) string {
+	specifier, ok := release.UpdateRange(specifier, version)
+	Expect(ok).To(BeTrue())
+	return specifier
+}
+
+var _ = Describe("Release", func() {
+	Describe("Choosing a bump from commit messages", func() {
+		It("Should choose the largest bump of any message", func() {
+			Expect(release.FromMessages([]string{"fix: a bug", "chore: tidy up"})).To(Equal(release.Patch))
+			Expect(release.FromMessages([]string{"fix: a bug", "feat(api): a feature"})).To(Equal(release.Minor))
+			Expect(release.FromMessages([]string{"feat!: drop node 8", "fix: a bug"})).To(Equal(release.Major))
+			Expect(release.FromMessages([]string{"refactor: things\n\nBREAKING CHANGE: renames the export"})).To(Equal(release.Major))
+		})
+
+		It("Should treat messages which are not conventional commits as patches", func() {
+			Expect(release.FromMessages([]string{"update readme"})).To(Equal(release.Patch))
+			Expect(release.FromMessages(nil)).To(Equal(release.None))
+		})
+	})
+
+	Describe("Incrementing versions", func() {
+		It("Should increment the version and reset lower components", func() {
+			Expect(release.Increment("1.4.2", release.Patch)).To(Equal("1.4.3"))
+			Expect(release.Increment("1.4.2", release.Minor)).To(Equal("1.5.0"))
+			Expect(release.Increment("1.4.2-story-test.abc1234", release.Major)).To(Equal("2.0.0"))
+		})
+
+		It("Should return an error for versions which are not semantic versions", func() {
+			_, err := release.Increment("latest", release.Patch)
+			Expect(err).To(MatchError(release.ErrInvalidVersion("latest")))
+		})
+	})
+
+	Describe("Updating dependency ranges", func() {
+		It("Should keep the range operator", func() {
+			Expect(updated("^1.4.0", "1.5.0")).To(Equal("^1.5.0"))
+			Expect(updated("~1.4.0", "1.4.1")).To(Equal("~1.4.1"))
+			Expect(updated("1.4.0", "2.0.0")).To(Equal("2.0.0"))
+		})
+
+		It("Should point git dependencies at the release tag", func() {
+			Expect(updated("git+ssh://git@github.com:test-org/two.git#test-story", "1.5.0")).To(Equal("git+ssh://git@github.com:test-org/two.git#v1.5.0"))
+		})
+
+		It("Should leave other specifiers unchanged", func() {
+			specifier, updated := release.UpdateRange("file:../two", "1.5.0")
+			Expect(specifier).To(Equal("file:../two"))
+			Expect(updated).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes results as a JUnit XML report with a test case for each project
+func WriteJUnit(w io.Writer, name string, results []Result) error {
+	suite := junitTestSuite{Name: name}
+
+	var total time.Duration
+	for _, r := range results {
+		testCase := junitTestCase{Name: r.Project, ClassName: name, Time: seconds(r.Duration), SystemOut: r.Output}
+
+		switch {
+		case r.Skipped != "":
+			testCase.Skipped = &junitMessage{Message: r.Skipped}
+			suite.Skipped++
+		case r.Err != nil:
+			testCase.Failure = &junitMessage{Message: r.Err.Error()}
+			suite.Failures++
+		case r.ExitCode != 0:
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("exit code %d", r.ExitCode), Contents: r.Output}
+			suite.Failures++
+		}
+
+		total += r.Duration
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suite.Time = seconds(total)
+
+	suites := junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
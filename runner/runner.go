@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Task is a command to run in a project directory
+type Task struct {
+	Project string
+	Command []string
+}
+
+// Result is the combined output and exit code of a task. Err is only set when the
+// command could not be started at all, and Skipped is the reason a task was not run.
+type Result struct {
+	Project  string
+	Output   string
+	ExitCode int
+	Duration time.Duration
+	Err      error
+	Skipped  string
+}
+
+func (r Result) Failed() bool {
+	return r.Err != nil || r.ExitCode != 0
+}
+
+// Run runs tasks with at most parallelism running at once, and returns their results
+// sorted by project. A parallelism of 0 or less uses the number of CPUs.
+func Run(tasks []Task, parallelism int) []Result {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(tasks))
+	semaphore := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task Task) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = run(task)
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Project < results[j].Project
+	})
+
+	return results
+}
+
+func run(task Task) Result {
+	result := Result{Project: task.Project}
+	if len(task.Command) == 0 {
+		return result
+	}
+
+	var output bytes.Buffer
+	command := exec.Command(task.Command[0], task.Command[1:]...)
+	command.Dir = task.Project
+	command.Stdout = &output
+	command.Stderr = &output
+
+	start := time.Now()
+	err := command.Run()
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+
+	if exitError, ok := err.(*exec.ExitError); ok {
+		result.ExitCode = exitError.ExitCode()
+	} else if err != nil {
+		result.Err = err
+		result.ExitCode = -1
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package runner_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LGUG2Z/story/runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runner", func() {
+	var directory string
+
+	BeforeEach(func() {
+		var err error
+		directory, err = ioutil.TempDir("", "runner")
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, project := range []string{"one", "two"} {
+			Expect(os.MkdirAll(filepath.Join(directory, project), os.FileMode(0700))).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(directory)).To(Succeed())
+	})
+
+	It("Should run commands in each project directory and capture their output", func() {
+		results := runner.Run([]runner.Task{
+			{Project: filepath.Join(directory, "two"), Command: []string{"sh", "-c", "basename $(pwd); exit 3"}},
+			{Project: filepath.Join(directory, "one"), Command: []string{"sh", "-c", "basename $(pwd)"}},
+		}, 2)
+
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Output).To(Equal("one\n"))
+		Expect(results[0].Failed()).To(BeFalse())
+		Expect(results[1].Output).To(Equal("two\n"))
+		Expect(results[1].ExitCode).To(Equal(3))
+		Expect(results[1].Failed()).To(BeTrue())
+	})
+
+	It("Should report commands which can not be started", func() {
+		results := runner.Run([]runner.Task{{Project: filepath.Join(directory, "one"), Command: []string{"story-command-that-does-not-exist"}}}, 0)
+
+		Expect(results[0].Err).To(HaveOccurred())
+		Expect(results[0].Failed()).To(BeTrue())
+	})
+
+	It("Should write results as a JUnit summary", func() {
+		var buf bytes.Buffer
+		Expect(runner.WriteJUnit(&buf, "test-story", []runner.Result{
+			{Project: "one", Output: "ok\n", Duration: 1500 * time.Millisecond},
+			{Project: "two", Output: "broken\n", ExitCode: 1},
+			{Project: "three", Skipped: "two did not pass"},
+		})).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring(`<testsuite name="test-story" tests="3" failures="1" skipped="1" time="1.500">`))
+		Expect(buf.String()).To(ContainSubstring(`<failure message="exit code 1">broken`))
+		Expect(buf.String()).To(ContainSubstring(`<skipped message="two did not pass"></skipped>`))
+	})
+})